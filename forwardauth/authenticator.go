@@ -0,0 +1,15 @@
+package forwardauth
+
+import (
+	"net/http"
+)
+
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// Authenticator resolves an identity from the request itself, without calling the auth url.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Identity, bool)
+}
@@ -0,0 +1,121 @@
+package forwardauth
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// CertRule maps a verified client certificate to an identity. Every non-empty match field
+// must match, patterns use path.Match syntax. When User is empty the matched value is used,
+// preferring CommonName, then URI, DNSName and Email.
+type CertRule struct {
+	CommonName string
+	URI        string
+	DNSName    string
+	Email      string
+	User       string
+	Groups     []string
+}
+
+type ClientCertAuthenticator struct {
+	rules []CertRule
+}
+
+// Interface guard
+var _ Authenticator = (*ClientCertAuthenticator)(nil)
+
+func NewClientCertAuthenticator(rules ...CertRule) *ClientCertAuthenticator {
+	return &ClientCertAuthenticator{rules: rules}
+}
+
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) (Identity, bool) {
+	cert := verifiedClientCert(r)
+	if cert == nil {
+		return Identity{}, false
+	}
+
+	var uris []string
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+
+	for _, rule := range a.rules {
+		if id, ok := rule.match(cert, uris); ok {
+			return id, true
+		}
+	}
+	return Identity{}, false
+}
+
+func (rule CertRule) match(cert *x509.Certificate, uris []string) (Identity, bool) {
+	if rule.CommonName == "" && rule.URI == "" && rule.DNSName == "" && rule.Email == "" {
+		return Identity{}, false
+	}
+
+	var matched []string
+	checks := []struct {
+		pattern string
+		values  []string
+	}{
+		{rule.CommonName, []string{cert.Subject.CommonName}},
+		{rule.URI, uris},
+		{rule.DNSName, cert.DNSNames},
+		{rule.Email, cert.EmailAddresses},
+	}
+	for _, check := range checks {
+		if check.pattern == "" {
+			continue
+		}
+		value, ok := matchAny(check.pattern, check.values)
+		if !ok {
+			return Identity{}, false
+		}
+		matched = append(matched, value)
+	}
+
+	user := rule.User
+	if user == "" {
+		user = matched[0]
+	}
+	return Identity{User: user, Groups: rule.Groups}, true
+}
+
+func matchAny(pattern string, values []string) (string, bool) {
+	for _, v := range values {
+		if ok, _ := path.Match(pattern, v); ok && v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func verifiedClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// formatClientCert renders the certificate in the Envoy X-Forwarded-Client-Cert format.
+func formatClientCert(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	parts := []string{
+		"Hash=" + hex.EncodeToString(hash[:]),
+		"Subject=" + quoteClientCertValue(cert.Subject.String()),
+	}
+	for _, u := range cert.URIs {
+		parts = append(parts, "URI="+quoteClientCertValue(u.String()))
+	}
+	for _, dns := range cert.DNSNames {
+		parts = append(parts, "DNS="+quoteClientCertValue(dns))
+	}
+	return strings.Join(parts, ";")
+}
+
+func quoteClientCertValue(v string) string {
+	return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+}
@@ -0,0 +1,113 @@
+package forwardauth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/forwardauth"
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClientCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/default/sa/billing")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "billing"},
+		DNSNames:     []string{"billing.default.svc"},
+		URIs:         []*url.URL{spiffe},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestClientCertAuthenticator(t *testing.T) {
+	cert := newClientCert(t)
+	verified := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+	t.Run("maps verified certificates by rule", func(t *testing.T) {
+		a := forwardauth.NewClientCertAuthenticator(
+			forwardauth.CertRule{CommonName: "payments"},
+			forwardauth.CertRule{URI: "spiffe://cluster.local/ns/default/sa/*", Groups: []string{"services"}},
+		)
+		request, _ := http.NewRequest(http.MethodGet, "/", nil)
+		request.TLS = verified
+
+		id, ok := a.Authenticate(request)
+		assert.True(t, ok)
+		assert.Equal(t, "spiffe://cluster.local/ns/default/sa/billing", id.User)
+		assert.Equal(t, []string{"services"}, id.Groups)
+	})
+
+	t.Run("requires every field of a rule to match", func(t *testing.T) {
+		a := forwardauth.NewClientCertAuthenticator(forwardauth.CertRule{CommonName: "billing", DNSName: "*.prod.svc", User: "billing"})
+		request, _ := http.NewRequest(http.MethodGet, "/", nil)
+		request.TLS = verified
+
+		_, ok := a.Authenticate(request)
+		assert.False(t, ok)
+	})
+
+	t.Run("ignores unverified certificates", func(t *testing.T) {
+		a := forwardauth.NewClientCertAuthenticator(forwardauth.CertRule{CommonName: "*"})
+		request, _ := http.NewRequest(http.MethodGet, "/", nil)
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+		_, ok := a.Authenticate(request)
+		assert.False(t, ok)
+	})
+
+	t.Run("forward auth skips auth url for mapped certificates", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			forwardauth.WithAuthenticator(forwardauth.NewClientCertAuthenticator(forwardauth.CertRule{CommonName: "billing", User: "svc-billing"})))
+
+		request, _ := http.NewRequest(http.MethodGet, "/someurl", nil)
+		request.TLS = verified
+		var seen *http.Request
+		f.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { seen = r }).ServeHTTP(httptest.NewRecorder(), request)
+
+		assert.Equal(t, "svc-billing", seen.Header.Get(headers.RemoteUser))
+		assert.Equal(t, 0, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("forwards client cert to auth url", func(t *testing.T) {
+		httpmock.Activate(t)
+		var forwarded string
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", func(req *http.Request) (*http.Response, error) {
+			forwarded = req.Header.Get(headers.XForwardedClientCert)
+			return httpmock.NewStringResponse(200, "OK"), nil
+		})
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithForwardClientCert())
+
+		request, _ := http.NewRequest(http.MethodGet, "/someurl", nil)
+		request.TLS = verified
+		f.HandlerFunc(func(http.ResponseWriter, *http.Request) { /* no-op */ }).ServeHTTP(httptest.NewRecorder(), request)
+
+		assert.Contains(t, forwarded, "Hash=")
+		assert.Contains(t, forwarded, `Subject="CN=billing"`)
+		assert.Contains(t, forwarded, `URI="spiffe://cluster.local/ns/default/sa/billing"`)
+		assert.Contains(t, forwarded, `DNS="billing.default.svc"`)
+	})
+}
@@ -3,7 +3,9 @@ package forwardauth
 import (
 	"encoding/base64"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cego/go-lib/v2/headers"
@@ -19,21 +21,51 @@ func WithHTTPClient(httpClient *http.Client) OptionFunc {
 	}
 }
 
+// WithAuthenticator adds an Authenticator that is consulted before the session cookie and
+// the auth url. Authenticators are tried in the order they were added.
+func WithAuthenticator(a Authenticator) OptionFunc {
+	return func(f *ForwardAuth) {
+		f.authenticators = append(f.authenticators, a)
+	}
+}
+
+// WithForwardClientCert forwards the verified client certificate to the auth url in the
+// X-Forwarded-Client-Cert header.
+func WithForwardClientCert() OptionFunc {
+	return func(f *ForwardAuth) {
+		f.forwardClientCert = true
+	}
+}
+
 type ForwardAuth struct {
-	logger         logger.Logger
+	logger         logger.ContextLogger
 	url            string
 	xForwardedHost string
 	httpClient     *http.Client
 	renderer       *renderer.Renderer
+	session        *sessionCookie
+	cache          *decisionCache
+	revoked        revocations
+	authenticators []Authenticator
+	metrics        Metrics
+
+	forwardClientCert bool
+	credentialCookie  string
+	sessionScope      func(r *http.Request) string
 }
 
 func New(l logger.Logger, url string, xForwardedHost string, opts ...OptionFunc) *ForwardAuth {
+	if sl, ok := l.(*slog.Logger); ok {
+		l = logger.WithName(sl, "forwardauth")
+	}
+
 	f := &ForwardAuth{
-		logger:         l,
+		logger:         logger.AsContextLogger(l),
 		url:            url,
 		xForwardedHost: xForwardedHost,
 		httpClient:     &http.Client{Timeout: 10 * time.Second},
 		renderer:       renderer.New(l),
+		metrics:        noopMetrics{},
 	}
 
 	for _, opt := range opts {
@@ -49,10 +81,44 @@ func (f *ForwardAuth) HandlerFunc(handlerFunc http.HandlerFunc) http.Handler {
 
 func (f *ForwardAuth) Handler(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, a := range f.authenticators {
+			if id, ok := a.Authenticate(r); ok {
+				f.metrics.Bypass()
+				setIdentity(r, id)
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if f.session != nil {
+			payload, ok := f.session.read(r, f.scope(r))
+			hit := ok && !f.revoked.isRevoked(payload)
+			f.metrics.CacheLookup(CacheSession, hit)
+			if hit {
+				setIdentity(r, Identity{User: payload.User, Groups: payload.Groups})
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		fingerprint := Fingerprint(r, f.credentialCookie)
+		key := cacheKey(fingerprint, r)
+		generation := f.cache.currentGeneration()
+		if f.cache != nil && fingerprint != "" {
+			id, ok := f.cache.get(key)
+			f.metrics.CacheLookup(CacheDecision, ok)
+			if ok {
+				setIdentity(r, id)
+				f.writeSession(w, r, id, fingerprint)
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
 		req, err := http.NewRequest("GET", f.url, nil)
 		if err != nil {
-			f.renderer.Text(w, http.StatusInternalServerError, err.Error())
-			f.logger.Error(err.Error())
+			f.renderer.WithContext(r.Context()).Text(w, http.StatusInternalServerError, err.Error())
+			f.logger.ErrorContext(r.Context(), err.Error())
 			return
 		}
 
@@ -76,26 +142,68 @@ func (f *ForwardAuth) Handler(handler http.Handler) http.Handler {
 			req.Header.Set(headers.Authorization, "Basic "+usernamePasswordEncoded)
 		}
 
+		if f.forwardClientCert {
+			if cert := verifiedClientCert(r); cert != nil {
+				req.Header.Set(headers.XForwardedClientCert, formatClientCert(cert))
+			}
+		}
+
+		start := time.Now()
 		resp, err := f.httpClient.Do(req)
 		if err != nil {
-			f.renderer.Text(w, http.StatusInternalServerError, err.Error())
-			f.logger.Error(err.Error())
+			f.metrics.AuthCall(errorOutcome(err), 0, time.Since(start))
+			f.renderer.WithContext(r.Context()).Text(w, http.StatusInternalServerError, err.Error())
+			f.logger.ErrorContext(r.Context(), err.Error())
 			return
 		}
+		defer func() { _ = resp.Body.Close() }()
 
 		if resp.StatusCode != http.StatusOK {
+			f.metrics.AuthCall(OutcomeDenied, resp.StatusCode, time.Since(start))
 			bodyBytes, err := io.ReadAll(resp.Body)
 			if err != nil {
-				f.renderer.Text(w, http.StatusInternalServerError, err.Error())
-				f.logger.Error(err.Error())
+				f.renderer.WithContext(r.Context()).Text(w, http.StatusInternalServerError, err.Error())
+				f.logger.ErrorContext(r.Context(), err.Error())
 				return
 			}
-			f.renderer.Data(w, resp.StatusCode, bodyBytes, resp.Header.Get(headers.ContentType))
+			f.renderer.WithContext(r.Context()).Data(w, resp.StatusCode, bodyBytes, resp.Header.Get(headers.ContentType))
 			return
 		}
 
+		f.metrics.AuthCall(OutcomeAllowed, resp.StatusCode, time.Since(start))
+
 		r.Header.Set(headers.RemoteUser, resp.Header.Get(headers.RemoteUser))
+		r.Header.Set(headers.RemoteGroups, resp.Header.Get(headers.RemoteGroups))
+
+		id := Identity{User: resp.Header.Get(headers.RemoteUser), Groups: splitGroups(resp.Header.Get(headers.RemoteGroups))}
+		if fingerprint != "" {
+			f.cache.set(key, fingerprint, id, generation)
+		}
+		f.writeSession(w, r, id, fingerprint)
 
 		handler.ServeHTTP(w, r)
 	})
 }
+
+// scope returns the scope session cookies for r are bound to.
+func (f *ForwardAuth) scope(r *http.Request) string {
+	if f.sessionScope != nil {
+		return f.sessionScope(r)
+	}
+	return requestScope(r)
+}
+
+func setIdentity(r *http.Request, id Identity) {
+	r.Header.Set(headers.RemoteUser, id.User)
+	r.Header.Set(headers.RemoteGroups, strings.Join(id.Groups, ","))
+}
+
+// writeSession issues a session cookie for id, unless the auth url returned no Remote-User.
+func (f *ForwardAuth) writeSession(w http.ResponseWriter, r *http.Request, id Identity, fingerprint string) {
+	if f.session == nil || id.User == "" {
+		return
+	}
+	if err := f.session.write(w, id, fingerprint, f.scope(r)); err != nil {
+		f.logger.ErrorContext(r.Context(), err.Error())
+	}
+}
@@ -1,15 +1,22 @@
 package forwardauth_test
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cego/go-lib/v2/forwardauth"
 	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type TestAllGoodHandler struct{}
@@ -90,4 +97,69 @@ func TestForwardAuthHandler(t *testing.T) {
 		assert.Equal(t, 403, response.Code)
 		assert.Equal(t, "Valid login, but you have been forbidden", response.Body.String())
 	})
+
+	t.Run("forward auth logs auth calls through a logging transport", func(t *testing.T) {
+		httpmock.Activate(t)
+		defer httpmock.Reset()
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", httpmock.NewStringResponder(200, "OK"))
+
+		rec := logtest.New()
+		client := &http.Client{Timeout: 5 * time.Second, Transport: logger.NewTransport(rec.Logger(), nil)}
+		request, _ := http.NewRequest(http.MethodGet, "/someurl", nil)
+		request.Header.Set("Cookie", "sso=secret")
+
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithHTTPClient(client))
+		f.Handler(&TestAllGoodHandler{}).ServeHTTP(httptest.NewRecorder(), request)
+
+		rec.AssertLogged(t, slog.LevelDebug, "GET sso.example.com/auth 200", "http.response.status_code", 200)
+		assert.Contains(t, rec.Records()[0].Attrs["http.request.headers.raw"], "Cookie")
+		assert.NotContains(t, rec.Records()[0].Attrs["http.request.headers.raw"], "secret")
+	})
+
+	t.Run("forward auth handler logs errors with the request context", func(t *testing.T) {
+		l := &logger.Mock{}
+		httpmock.Activate(t)
+		defer httpmock.Reset()
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", httpmock.NewErrorResponder(errors.New("connection refused")))
+
+		ctx := context.WithValue(context.Background(), requestKey{}, "req-1")
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/someurl", nil)
+		response := httptest.NewRecorder()
+		l.On("ErrorContext", ctx, mock.MatchedBy(func(msg string) bool { return strings.Contains(msg, "connection refused") }), mock.Anything).Return()
+
+		f := forwardauth.New(l, "https://sso.example.com/auth", "example.com")
+		f.Handler(&TestAllGoodHandler{}).ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		l.AssertExpectations(t)
+	})
+
+	t.Run("forward auth handler closes auth responses", func(t *testing.T) {
+		for _, status := range []int{http.StatusOK, http.StatusUnauthorized} {
+			httpmock.Activate(t)
+			body := &closeRecorder{Reader: strings.NewReader("OK")}
+			httpmock.RegisterResponder("GET", "https://sso.example.com/auth", func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: status, Header: http.Header{}, Body: body}, nil
+			})
+
+			request, _ := http.NewRequest(http.MethodGet, "/someurl", nil)
+			f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com")
+			f.Handler(&TestAllGoodHandler{}).ServeHTTP(httptest.NewRecorder(), request)
+
+			assert.True(t, body.closed, "status %d", status)
+			httpmock.DeactivateAndReset()
+		}
+	})
+}
+
+type requestKey struct{}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
 }
@@ -0,0 +1,278 @@
+package forwardauth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cego/go-lib/v2/headers"
+)
+
+type decision struct {
+	identity    Identity
+	fingerprint string
+	expires     time.Time
+}
+
+type cachedDecision struct {
+	key string
+	decision
+}
+
+// decisionCache keeps up to maxEntries decisions, evicting the least recently used one.
+type decisionCache struct {
+	ttl        time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+	// generation is incremented by every invalidation, so decisions obtained before it are not stored.
+	generation uint64
+}
+
+// revocations records when users, credentials or everything were last invalidated, so session
+// cookies issued before that point are rejected until they would have expired anyway.
+type revocations struct {
+	mu           sync.RWMutex
+	all          time.Time
+	users        map[string]time.Time
+	fingerprints map[string]time.Time
+}
+
+type invalidationRequest struct {
+	User        string `json:"user"`
+	Fingerprint string `json:"fingerprint"`
+	All         bool   `json:"all"`
+}
+
+const DefaultDecisionCacheSize = 10000
+
+// WithDecisionCache caches successful auth decisions for ttl, keyed by the credential
+// fingerprint, method, host and path of the request. Requests without credentials are never
+// cached. Beyond maxEntries, DefaultDecisionCacheSize if it is 0, the least recently used
+// decision is evicted.
+func WithDecisionCache(ttl time.Duration, maxEntries int) OptionFunc {
+	return func(f *ForwardAuth) {
+		if maxEntries <= 0 {
+			maxEntries = DefaultDecisionCacheSize
+		}
+		f.cache = &decisionCache{ttl: ttl, maxEntries: maxEntries, entries: map[string]*list.Element{}, lru: list.New()}
+	}
+}
+
+// WithCredentialCookie names the session cookie of the SSO, which together with the
+// Authorization header identifies the credentials of a request, see Fingerprint. Without it,
+// requests authenticated by cookie alone have no fingerprint.
+func WithCredentialCookie(name string) OptionFunc {
+	return func(f *ForwardAuth) {
+		f.credentialCookie = name
+	}
+}
+
+// Fingerprint identifies the credentials of a request: the hex encoded SHA-256 of the value of
+// the cookie named cookieName, a newline and the Authorization header. Other cookies are ignored.
+func Fingerprint(r *http.Request, cookieName string) string {
+	var session string
+	if cookieName != "" {
+		if cookie, err := r.Cookie(cookieName); err == nil {
+			session = cookie.Value
+		}
+	}
+	authorization := r.Header.Get(headers.Authorization)
+	if session == "" && authorization == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(session + "\n" + authorization))
+	return hex.EncodeToString(hash[:])
+}
+
+// InvalidateUser drops cached decisions and session cookies belonging to user.
+func (f *ForwardAuth) InvalidateUser(user string) {
+	if f.session != nil {
+		f.revoked.revoke(&f.revoked.users, user, f.session.ttl)
+	}
+	f.cache.remove(func(d decision) bool { return d.identity.User == user })
+}
+
+// InvalidateFingerprint drops cached decisions and session cookies obtained with the credentials
+// identified by fingerprint, see Fingerprint and WithCredentialCookie.
+func (f *ForwardAuth) InvalidateFingerprint(fingerprint string) {
+	if f.session != nil {
+		f.revoked.revoke(&f.revoked.fingerprints, fingerprint, f.session.ttl)
+	}
+	f.cache.remove(func(d decision) bool { return d.fingerprint == fingerprint })
+}
+
+// InvalidateAll drops every cached decision and session cookie.
+func (f *ForwardAuth) InvalidateAll() {
+	f.revoked.mu.Lock()
+	f.revoked.all = time.Now()
+	f.revoked.users = nil
+	f.revoked.fingerprints = nil
+	f.revoked.mu.Unlock()
+	f.cache.remove(func(decision) bool { return true })
+}
+
+// maxInvalidationBody is the size limit of InvalidationHandler request bodies.
+const maxInvalidationBody = 4 << 10
+
+// InvalidationHandler accepts invalidations pushed by the SSO. Requests must be POSTs
+// authenticated with "Authorization: Bearer <secret>" and carry a JSON body like
+// {"user": "alice"}, {"fingerprint": "..."} or {"all": true}. Bodies larger than 4 KiB are
+// rejected with 413.
+func (f *ForwardAuth) InvalidationHandler(secret string) http.Handler {
+	expected := []byte("Bearer " + secret)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			f.renderer.WithContext(r.Context()).Text(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+			return
+		}
+		if secret == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(headers.Authorization)), expected) != 1 {
+			f.renderer.WithContext(r.Context()).Text(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		var body invalidationRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInvalidationBody)).Decode(&body); err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			f.renderer.WithContext(r.Context()).Text(w, status, err.Error())
+			return
+		}
+
+		switch {
+		case body.All:
+			f.InvalidateAll()
+		case body.User != "":
+			f.InvalidateUser(body.User)
+		case body.Fingerprint != "":
+			f.InvalidateFingerprint(body.Fingerprint)
+		default:
+			f.renderer.WithContext(r.Context()).Text(w, http.StatusBadRequest, "one of user, fingerprint or all is required")
+			return
+		}
+
+		f.logger.InfoContext(r.Context(), "forward auth decisions invalidated", "user", body.User, "fingerprint", body.Fingerprint, "all", body.All)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *decisionCache) get(key string) (Identity, bool) {
+	if c == nil {
+		return Identity{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return Identity{}, false
+	}
+	d := e.Value.(*cachedDecision)
+	if time.Now().After(d.expires) {
+		c.lru.Remove(e)
+		delete(c.entries, key)
+		return Identity{}, false
+	}
+	c.lru.MoveToFront(e)
+	return d.identity, true
+}
+
+// currentGeneration returns the generation to pass to set for a decision about to be obtained.
+func (c *decisionCache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// set stores a decision obtained in generation, unless an invalidation happened since.
+func (c *decisionCache) set(key string, fingerprint string, id Identity, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	d := decision{identity: id, fingerprint: fingerprint, expires: time.Now().Add(c.ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cachedDecision).decision = d
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cachedDecision{key: key, decision: d})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedDecision).key)
+	}
+}
+
+func (c *decisionCache) remove(match func(d decision) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for k, e := range c.entries {
+		if match(e.Value.(*cachedDecision).decision) {
+			c.lru.Remove(e)
+			delete(c.entries, k)
+		}
+	}
+}
+
+// revoke records key in m and forgets revocations older than maxAge, after which every
+// affected session cookie has expired.
+func (rv *revocations) revoke(m *map[string]time.Time, key string, maxAge time.Duration) {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	now := time.Now()
+	for _, existing := range []map[string]time.Time{rv.users, rv.fingerprints} {
+		for k, at := range existing {
+			if now.Sub(at) > maxAge {
+				delete(existing, k)
+			}
+		}
+	}
+
+	if *m == nil {
+		*m = map[string]time.Time{}
+	}
+	(*m)[key] = now
+}
+
+func (rv *revocations) isRevoked(p sessionPayload) bool {
+	rv.mu.RLock()
+	defer rv.mu.RUnlock()
+
+	issued := time.UnixMilli(p.Issued)
+	for _, at := range []time.Time{rv.all, rv.users[p.User], rv.fingerprints[p.Fingerprint]} {
+		if !at.IsZero() && !issued.After(at) {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(fingerprint string, r *http.Request) string {
+	return fingerprint + "\n" + requestScope(r)
+}
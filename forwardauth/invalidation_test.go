@@ -0,0 +1,160 @@
+package forwardauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/forwardauth"
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveWithCredentials(f *forwardauth.ForwardAuth, cookie string) *httptest.ResponseRecorder {
+	return serveURLWithCredentials(f, "/someurl", cookie)
+}
+
+func serveURLWithCredentials(f *forwardauth.ForwardAuth, url string, cookie string) *httptest.ResponseRecorder {
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	request.Header.Set(headers.Cookie, cookie)
+	response := httptest.NewRecorder()
+	f.HandlerFunc(func(http.ResponseWriter, *http.Request) { /* no-op */ }).ServeHTTP(response, request)
+	return response
+}
+
+func TestDecisionCache(t *testing.T) {
+	t.Run("caches decisions per credentials", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 0), forwardauth.WithCredentialCookie("sso"))
+
+		serveWithCredentials(f, "sso=alice")
+		serveWithCredentials(f, "sso=alice")
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+		serveWithCredentials(f, "sso=bob")
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("keys decisions by path and session cookie only", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 0), forwardauth.WithCredentialCookie("sso"))
+
+		serveURLWithCredentials(f, "/someurl?page=1", "sso=alice; theme=dark")
+		serveURLWithCredentials(f, "/someurl?page=2", "theme=light; sso=alice")
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+		serveURLWithCredentials(f, "/other", "sso=alice")
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+		// Other cookies are not credentials
+		serveWithCredentials(f, "theme=dark")
+		serveWithCredentials(f, "theme=dark")
+		assert.Equal(t, 4, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("evicts the least recently used decision", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 2), forwardauth.WithCredentialCookie("sso"))
+
+		serveWithCredentials(f, "sso=alice")
+		serveWithCredentials(f, "sso=bob")
+		serveWithCredentials(f, "sso=alice")
+		serveWithCredentials(f, "sso=carol")
+		assert.Equal(t, 3, httpmock.GetTotalCallCount())
+
+		serveWithCredentials(f, "sso=alice")
+		assert.Equal(t, 3, httpmock.GetTotalCallCount())
+		serveWithCredentials(f, "sso=bob")
+		assert.Equal(t, 4, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("does not store decisions invalidated during the auth call", func(t *testing.T) {
+		httpmock.Activate(t)
+		var f *forwardauth.ForwardAuth
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", func(r *http.Request) (*http.Response, error) {
+			f.InvalidateUser("alice")
+			resp := httpmock.NewStringResponse(http.StatusOK, "OK")
+			resp.Header.Set(headers.RemoteUser, "alice")
+			return resp, nil
+		})
+		f = forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 0), forwardauth.WithCredentialCookie("sso"))
+
+		serveWithCredentials(f, "sso=alice")
+		serveWithCredentials(f, "sso=alice")
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("does not cache anonymous requests", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 0), forwardauth.WithCredentialCookie("sso"))
+
+		serveWithCredentials(f, "")
+		serveWithCredentials(f, "")
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("invalidates by user, fingerprint and all", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 0), forwardauth.WithCredentialCookie("sso"))
+
+		serveWithCredentials(f, "sso=alice")
+		f.InvalidateUser("alice")
+		serveWithCredentials(f, "sso=alice")
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+		request, _ := http.NewRequest(http.MethodGet, "/someurl", nil)
+		request.Header.Set(headers.Cookie, "sso=alice")
+		f.InvalidateFingerprint(forwardauth.Fingerprint(request, "sso"))
+		serveWithCredentials(f, "sso=alice")
+		assert.Equal(t, 3, httpmock.GetTotalCallCount())
+
+		f.InvalidateAll()
+		serveWithCredentials(f, "sso=alice")
+		assert.Equal(t, 4, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("invalidation revokes session cookies", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1))
+
+		response, _ := serveWithCookie(f, nil)
+		cookie := sessionCookieFrom(t, response)
+		f.InvalidateUser("bob")
+		serveWithCookie(f, cookie)
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+		f.InvalidateUser("alice")
+		serveWithCookie(f, cookie)
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+}
+
+func TestInvalidationHandler(t *testing.T) {
+	registerSSO(t)
+	f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com", forwardauth.WithDecisionCache(time.Minute, 0), forwardauth.WithCredentialCookie("sso"))
+	h := f.InvalidationHandler("s3cret")
+
+	invalidate := func(method string, authorization string, body string) int {
+		request := httptest.NewRequest(method, "/invalidate", strings.NewReader(body))
+		request.Header.Set(headers.Authorization, authorization)
+		response := httptest.NewRecorder()
+		h.ServeHTTP(response, request)
+		return response.Code
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, invalidate(http.MethodGet, "Bearer s3cret", ""))
+	assert.Equal(t, http.StatusUnauthorized, invalidate(http.MethodPost, "Bearer wrong", `{"all":true}`))
+	assert.Equal(t, http.StatusBadRequest, invalidate(http.MethodPost, "Bearer s3cret", `{}`))
+	assert.Equal(t, http.StatusBadRequest, invalidate(http.MethodPost, "Bearer s3cret", `not json`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, invalidate(http.MethodPost, "Bearer s3cret", `{"user":"`+strings.Repeat("a", 8<<10)+`"}`))
+
+	serveWithCredentials(f, "sso=alice")
+	assert.Equal(t, http.StatusNoContent, invalidate(http.MethodPost, "Bearer s3cret", `{"user":"alice"}`))
+	serveWithCredentials(f, "sso=alice")
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
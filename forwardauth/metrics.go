@@ -0,0 +1,95 @@
+package forwardauth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/cego/go-lib/v2/metrics"
+)
+
+type Outcome string
+
+const (
+	OutcomeAllowed Outcome = "allowed"
+	OutcomeDenied  Outcome = "denied"
+	OutcomeError   Outcome = "error"
+	OutcomeTimeout Outcome = "timeout"
+)
+
+type Cache string
+
+const (
+	CacheSession  Cache = "session"
+	CacheDecision Cache = "decision"
+)
+
+// Metrics receives auth outcomes from ForwardAuth.
+type Metrics interface {
+	// AuthCall is called after every call to the auth url, status is 0 for errors and timeouts.
+	AuthCall(outcome Outcome, status int, duration time.Duration)
+	// CacheLookup is called for every lookup in the session cookie or the decision cache.
+	CacheLookup(cache Cache, hit bool)
+	// Bypass is called when an Authenticator resolved the identity.
+	Bypass()
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) AuthCall(Outcome, int, time.Duration) { /* no-op */ }
+
+func (noopMetrics) CacheLookup(Cache, bool) { /* no-op */ }
+
+func (noopMetrics) Bypass() { /* no-op */ }
+
+func WithMetrics(m Metrics) OptionFunc {
+	return func(f *ForwardAuth) {
+		f.metrics = m
+	}
+}
+
+type PrometheusMetrics struct {
+	authCalls    *metrics.Counter
+	authDuration *metrics.Histogram
+	cacheLookups *metrics.Counter
+	bypasses     *metrics.Counter
+}
+
+// Interface guard
+var _ Metrics = (*PrometheusMetrics)(nil)
+
+func NewPrometheusMetrics(reg *metrics.Registry) *PrometheusMetrics {
+	return &PrometheusMetrics{
+		authCalls:    reg.Counter("forwardauth_auth_calls_total", "Calls to the auth url by outcome and status.", "outcome", "status"),
+		authDuration: reg.Histogram("forwardauth_auth_call_duration_seconds", "Duration of calls to the auth url by outcome.", nil, "outcome"),
+		cacheLookups: reg.Counter("forwardauth_cache_lookups_total", "Session cookie and decision cache lookups by result.", "cache", "result"),
+		bypasses:     reg.Counter("forwardauth_bypass_total", "Requests authenticated by an authenticator without calling the auth url."),
+	}
+}
+
+func (p *PrometheusMetrics) AuthCall(outcome Outcome, status int, duration time.Duration) {
+	p.authCalls.Inc(string(outcome), strconv.Itoa(status))
+	p.authDuration.Observe(duration.Seconds(), string(outcome))
+}
+
+func (p *PrometheusMetrics) CacheLookup(cache Cache, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	p.cacheLookups.Inc(string(cache), result)
+}
+
+func (p *PrometheusMetrics) Bypass() {
+	p.bypasses.Inc()
+}
+
+func errorOutcome(err error) Outcome {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return OutcomeTimeout
+	}
+	return OutcomeError
+}
@@ -0,0 +1,77 @@
+package forwardauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/forwardauth"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/metrics"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exposition(t *testing.T, reg *metrics.Registry) string {
+	t.Helper()
+	var sb strings.Builder
+	_, err := reg.WriteTo(&sb)
+	require.NoError(t, err)
+	return sb.String()
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	t.Run("counts auth call outcomes", func(t *testing.T) {
+		httpmock.Activate(t)
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", httpmock.NewStringResponder(200, "OK"))
+		httpmock.RegisterResponder("GET", "https://sso.example.com/denied", httpmock.NewStringResponder(403, "Forbidden"))
+		httpmock.RegisterResponder("GET", "https://sso.example.com/broken", httpmock.NewErrorResponder(errors.New("connection refused")))
+		httpmock.RegisterResponder("GET", "https://sso.example.com/slow", httpmock.NewErrorResponder(context.DeadlineExceeded))
+
+		reg := metrics.NewRegistry()
+		m := forwardauth.NewPrometheusMetrics(reg)
+		for _, url := range []string{"auth", "denied", "broken", "slow"} {
+			f := forwardauth.New(logger.NewMock(), "https://sso.example.com/"+url, "example.com", forwardauth.WithMetrics(m))
+			serveWithCredentials(f, "")
+		}
+
+		out := exposition(t, reg)
+		assert.Contains(t, out, `forwardauth_auth_calls_total{outcome="allowed",status="200"} 1`)
+		assert.Contains(t, out, `forwardauth_auth_calls_total{outcome="denied",status="403"} 1`)
+		assert.Contains(t, out, `forwardauth_auth_calls_total{outcome="error",status="0"} 1`)
+		assert.Contains(t, out, `forwardauth_auth_calls_total{outcome="timeout",status="0"} 1`)
+		assert.Contains(t, out, `forwardauth_auth_call_duration_seconds_count{outcome="allowed"} 1`)
+	})
+
+	t.Run("counts cache lookups and bypasses", func(t *testing.T) {
+		registerSSO(t)
+		reg := metrics.NewRegistry()
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			forwardauth.WithMetrics(forwardauth.NewPrometheusMetrics(reg)),
+			forwardauth.WithDecisionCache(time.Minute, 0),
+			forwardauth.WithCredentialCookie("sso"),
+			forwardauth.WithAuthenticator(bypassAuthenticator{}))
+
+		serveWithCredentials(f, "sso=alice")
+		serveWithCredentials(f, "sso=alice")
+		request, _ := http.NewRequest(http.MethodGet, "/someurl", nil)
+		request.Header.Set("X-Bypass", "yes")
+		f.HandlerFunc(func(http.ResponseWriter, *http.Request) { /* no-op */ }).ServeHTTP(httptest.NewRecorder(), request)
+
+		out := exposition(t, reg)
+		assert.Contains(t, out, `forwardauth_cache_lookups_total{cache="decision",result="hit"} 1`)
+		assert.Contains(t, out, `forwardauth_cache_lookups_total{cache="decision",result="miss"} 1`)
+		assert.Contains(t, out, "forwardauth_bypass_total 1")
+	})
+}
+
+type bypassAuthenticator struct{}
+
+func (bypassAuthenticator) Authenticate(r *http.Request) (forwardauth.Identity, bool) {
+	return forwardauth.Identity{User: "bypass"}, r.Header.Get("X-Bypass") == "yes"
+}
@@ -0,0 +1,223 @@
+package forwardauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var errInvalidSession = errors.New("invalid session cookie")
+
+// ErrInvalidSessionKey is returned by WithSessionCookie and WithEncryptedSessionCookie for missing
+// keys or keys shorter than MinSessionKeyLength.
+var ErrInvalidSessionKey = errors.New("invalid session cookie key")
+
+// MinSessionKeyLength is the minimum length of session cookie keys in bytes.
+const MinSessionKeyLength = 32
+
+type sessionPayload struct {
+	User        string   `json:"u"`
+	Groups      []string `json:"g,omitempty"`
+	Fingerprint string   `json:"f,omitempty"`
+	Scope       string   `json:"s"`
+	Issued      int64    `json:"i"`
+	Expires     int64    `json:"e"`
+}
+
+type sessionCookie struct {
+	name    string
+	ttl     time.Duration
+	keys    [][]byte
+	encrypt bool
+}
+
+// WithSessionCookie makes ForwardAuth issue an HMAC-SHA256 signed session cookie after a
+// successful auth call and accept it on later requests until it expires. The first key
+// signs new cookies, all keys are accepted for verification to allow key rotation. Keys must
+// be at least MinSessionKeyLength bytes of random data.
+//
+// The auth url decides per request, so a cookie is only accepted for the method, host and path
+// it was issued for, see WithSessionScope.
+func WithSessionCookie(name string, ttl time.Duration, keys ...[]byte) (OptionFunc, error) {
+	return withSessionCookie(name, ttl, keys, false)
+}
+
+// WithEncryptedSessionCookie works like WithSessionCookie, but encrypts the cookie with
+// AES-256-GCM so user and groups are not readable by the client.
+func WithEncryptedSessionCookie(name string, ttl time.Duration, keys ...[]byte) (OptionFunc, error) {
+	return withSessionCookie(name, ttl, keys, true)
+}
+
+func withSessionCookie(name string, ttl time.Duration, keys [][]byte, encrypt bool) (OptionFunc, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", ErrInvalidSessionKey)
+	}
+	for i, key := range keys {
+		if len(key) < MinSessionKeyLength {
+			return nil, fmt.Errorf("%w: key %d has %d bytes, at least %d are required", ErrInvalidSessionKey, i, len(key), MinSessionKeyLength)
+		}
+	}
+
+	return func(f *ForwardAuth) {
+		f.session = &sessionCookie{name: name, ttl: ttl, keys: keys, encrypt: encrypt}
+	}, nil
+}
+
+// WithSessionScope sets the scope session cookies are bound to, instead of the method, host and
+// path of the request. A cookie issued for one request is accepted for every request with the same
+// scope without calling the auth url, so only use a wider scope, e.g. r.Host, if the auth url
+// decides the same for all of them.
+func WithSessionScope(scope func(r *http.Request) string) OptionFunc {
+	return func(f *ForwardAuth) {
+		f.sessionScope = scope
+	}
+}
+
+// requestScope is the scope of the decision of the auth url for r.
+func requestScope(r *http.Request) string {
+	return strings.Join([]string{r.Method, r.Host, r.URL.Path}, "\n")
+}
+
+func (s *sessionCookie) read(r *http.Request, scope string) (sessionPayload, bool) {
+	cookie, err := r.Cookie(s.name)
+	if err != nil {
+		return sessionPayload{}, false
+	}
+	payload, err := s.decode(cookie.Value)
+	if err != nil || payload.Scope != scope || time.Now().UnixMilli() >= payload.Expires {
+		return sessionPayload{}, false
+	}
+	return payload, true
+}
+
+func (s *sessionCookie) write(w http.ResponseWriter, id Identity, fingerprint string, scope string) error {
+	now := time.Now()
+	value, err := s.encode(sessionPayload{
+		User:        id.User,
+		Groups:      id.Groups,
+		Fingerprint: fingerprint,
+		Scope:       scope,
+		Issued:      now.UnixMilli(),
+		Expires:     now.Add(s.ttl).UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(s.ttl.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (s *sessionCookie) encode(payload sessionPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	if s.encrypt {
+		aead, err := newAEAD(s.keys[0])
+		if err != nil {
+			return "", err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		sealed := aead.Seal(nonce, nonce, data, []byte(s.name))
+		return base64.RawURLEncoding.EncodeToString(sealed), nil
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	signature := base64.RawURLEncoding.EncodeToString(s.sign(s.keys[0], encoded))
+	return encoded + "." + signature, nil
+}
+
+func (s *sessionCookie) decode(value string) (sessionPayload, error) {
+	var payload sessionPayload
+
+	data, err := s.open(value)
+	if err != nil {
+		return payload, err
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return payload, errInvalidSession
+	}
+	return payload, nil
+}
+
+func (s *sessionCookie) open(value string) ([]byte, error) {
+	if s.encrypt {
+		sealed, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errInvalidSession
+		}
+		for _, key := range s.keys {
+			aead, err := newAEAD(key)
+			if err != nil || len(sealed) < aead.NonceSize() {
+				continue
+			}
+			nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+			if data, err := aead.Open(nil, nonce, ciphertext, []byte(s.name)); err == nil {
+				return data, nil
+			}
+		}
+		return nil, errInvalidSession
+	}
+
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, errInvalidSession
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, errInvalidSession
+	}
+	for _, key := range s.keys {
+		if hmac.Equal(mac, s.sign(key, encoded)) {
+			return base64.RawURLEncoding.DecodeString(encoded)
+		}
+	}
+	return nil, errInvalidSession
+}
+
+// sign binds data to the cookie name, so a cookie can not be replayed under another name.
+func (s *sessionCookie) sign(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s.name + "\n" + data))
+	return h.Sum(nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	derived := sha256.Sum256(key)
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func splitGroups(value string) []string {
+	var groups []string
+	for _, g := range strings.Split(value, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
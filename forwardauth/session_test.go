@@ -0,0 +1,223 @@
+package forwardauth_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/forwardauth"
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, forwardauth.MinSessionKeyLength)
+	key2 = bytes.Repeat([]byte{2}, forwardauth.MinSessionKeyLength)
+)
+
+func signedCookie(t *testing.T, name string, ttl time.Duration, keys ...[]byte) forwardauth.OptionFunc {
+	t.Helper()
+	opt, err := forwardauth.WithSessionCookie(name, ttl, keys...)
+	require.NoError(t, err)
+	return opt
+}
+
+func encryptedCookie(t *testing.T, name string, ttl time.Duration, keys ...[]byte) forwardauth.OptionFunc {
+	t.Helper()
+	opt, err := forwardauth.WithEncryptedSessionCookie(name, ttl, keys...)
+	require.NoError(t, err)
+	return opt
+}
+
+func registerSSO(t *testing.T) {
+	t.Helper()
+	httpmock.Activate(t)
+	responder := httpmock.NewStringResponder(200, "OK").
+		HeaderSet(http.Header{headers.RemoteUser: {"alice"}, headers.RemoteGroups: {"admins, devs"}})
+	httpmock.RegisterResponder("GET", "https://sso.example.com/auth", responder)
+}
+
+func serveWithCookie(f *forwardauth.ForwardAuth, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Request) {
+	return serveRequestWithCookie(f, http.MethodGet, "/someurl", cookie)
+}
+
+func serveRequestWithCookie(f *forwardauth.ForwardAuth, method string, url string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Request) {
+	var seen *http.Request
+	request, _ := http.NewRequest(method, url, nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	response := httptest.NewRecorder()
+	f.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r
+		_, _ = w.Write([]byte("All good !!!"))
+	}).ServeHTTP(response, request)
+	return response, seen
+}
+
+func sessionCookieFrom(t *testing.T, response *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, c := range response.Result().Cookies() {
+		if c.Name == "fa_session" {
+			return c
+		}
+	}
+	require.Fail(t, "session cookie not set")
+	return nil
+}
+
+func TestSessionCookie(t *testing.T) {
+	t.Run("issues cookie and skips auth call while valid", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1))
+
+		response, _ := serveWithCookie(f, nil)
+		cookie := sessionCookieFrom(t, response)
+		assert.True(t, cookie.HttpOnly)
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+		response, seen := serveWithCookie(f, cookie)
+		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, "alice", seen.Header.Get(headers.RemoteUser))
+		assert.Equal(t, "admins,devs", seen.Header.Get(headers.RemoteGroups))
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("accepts cookies signed with a rotated key", func(t *testing.T) {
+		registerSSO(t)
+		old := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1))
+		rotated := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key2, key1))
+
+		response, _ := serveWithCookie(old, nil)
+		_, seen := serveWithCookie(rotated, sessionCookieFrom(t, response))
+		assert.Equal(t, "alice", seen.Header.Get(headers.RemoteUser))
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("rejects tampered and expired cookies", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", 20*time.Millisecond, key1))
+
+		response, _ := serveWithCookie(f, nil)
+		cookie := sessionCookieFrom(t, response)
+
+		_, _ = serveWithCookie(f, &http.Cookie{Name: cookie.Name, Value: cookie.Value + "x"})
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+		time.Sleep(30 * time.Millisecond)
+		_, _ = serveWithCookie(f, cookie)
+		assert.Equal(t, 3, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("encrypted cookie round trips and hides identity", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			encryptedCookie(t, "fa_session", time.Minute, key1))
+
+		response, _ := serveWithCookie(f, nil)
+		cookie := sessionCookieFrom(t, response)
+		assert.NotContains(t, cookie.Value, ".")
+
+		_, seen := serveWithCookie(f, cookie)
+		assert.Equal(t, "alice", seen.Header.Get(headers.RemoteUser))
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+		other := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			encryptedCookie(t, "fa_session", time.Minute, key2))
+		_, _ = serveWithCookie(other, cookie)
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("rejects missing and short keys", func(t *testing.T) {
+		_, err := forwardauth.WithSessionCookie("fa_session", time.Minute)
+		require.ErrorIs(t, err, forwardauth.ErrInvalidSessionKey)
+
+		_, err = forwardauth.WithEncryptedSessionCookie("fa_session", time.Minute, key1, []byte("key-2"))
+		require.ErrorIs(t, err, forwardauth.ErrInvalidSessionKey)
+		assert.ErrorContains(t, err, "key 1 has 5 bytes")
+	})
+
+	t.Run("does not issue cookies without a user", func(t *testing.T) {
+		httpmock.Activate(t)
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", httpmock.NewStringResponder(200, "OK"))
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1))
+
+		response, _ := serveWithCookie(f, nil)
+		assert.Equal(t, 200, response.Code)
+		assert.Empty(t, response.Result().Cookies())
+	})
+
+	t.Run("rejects cookies issued under another name", func(t *testing.T) {
+		registerSSO(t)
+		other := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "other_session", time.Minute, key1))
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1))
+
+		response, _ := serveWithCookie(other, nil)
+		issued := response.Result().Cookies()
+		require.Len(t, issued, 1)
+
+		_, _ = serveWithCookie(f, &http.Cookie{Name: "fa_session", Value: issued[0].Value})
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("binds cookies to the method, host and path they were issued for", func(t *testing.T) {
+		httpmock.Activate(t)
+		// The auth url allows /public only
+		httpmock.RegisterResponder("GET", "https://sso.example.com/auth", func(r *http.Request) (*http.Response, error) {
+			if r.Header.Get(headers.XForwardedUri) != "/public" {
+				return httpmock.NewStringResponse(http.StatusForbidden, "Forbidden"), nil
+			}
+			resp := httpmock.NewStringResponse(http.StatusOK, "OK")
+			resp.Header.Set(headers.RemoteUser, "alice")
+			return resp, nil
+		})
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1))
+
+		response, _ := serveRequestWithCookie(f, http.MethodGet, "/public", nil)
+		cookie := sessionCookieFrom(t, response)
+
+		response, _ = serveRequestWithCookie(f, http.MethodGet, "/admin", cookie)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+		_, _ = serveRequestWithCookie(f, http.MethodPost, "/public", cookie)
+		assert.Equal(t, 3, httpmock.GetTotalCallCount())
+		_, _ = serveRequestWithCookie(f, http.MethodGet, "https://other.example.com/public", cookie)
+		assert.Equal(t, 4, httpmock.GetTotalCallCount())
+
+		response, _ = serveRequestWithCookie(f, http.MethodGet, "/public", cookie)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, 4, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("accepts cookies for every request of a wider scope", func(t *testing.T) {
+		registerSSO(t)
+		f := forwardauth.New(logger.NewMock(), "https://sso.example.com/auth", "example.com",
+			signedCookie(t, "fa_session", time.Minute, key1),
+			forwardauth.WithSessionScope(func(r *http.Request) string { return r.Host }))
+
+		response, _ := serveRequestWithCookie(f, http.MethodGet, "https://app.example.com/public", nil)
+		cookie := sessionCookieFrom(t, response)
+
+		_, seen := serveRequestWithCookie(f, http.MethodPost, "https://app.example.com/admin", cookie)
+		assert.Equal(t, "alice", seen.Header.Get(headers.RemoteUser))
+		assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+		_, _ = serveRequestWithCookie(f, http.MethodGet, "https://other.example.com/public", cookie)
+		assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+}
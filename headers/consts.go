@@ -1,15 +1,19 @@
 package headers
 
 const (
-	XForwardedProto  = "X-Forwarded-Proto"
-	XForwardedMethod = "X-Forwarded-Method"
-	XForwardedHost   = "X-Forwarded-Host"
-	XForwardedUri    = "X-Forwarded-Uri"
-	XForwardedFor    = "X-Forwarded-For"
-	Accept           = "Accept"
-	UserAgent        = "User-Agent"
-	Cookie           = "Cookie"
-	Authorization    = "Authorization"
-	RemoteUser       = "Remote-User"
-	ContentType      = "Content-Type"
+	XForwardedProto      = "X-Forwarded-Proto"
+	XForwardedMethod     = "X-Forwarded-Method"
+	XForwardedHost       = "X-Forwarded-Host"
+	XForwardedUri        = "X-Forwarded-Uri"
+	XForwardedFor        = "X-Forwarded-For"
+	XForwardedClientCert = "X-Forwarded-Client-Cert"
+	Accept               = "Accept"
+	UserAgent            = "User-Agent"
+	Cookie               = "Cookie"
+	Authorization        = "Authorization"
+	RemoteUser           = "Remote-User"
+	RemoteGroups         = "Remote-Groups"
+	ContentType          = "Content-Type"
+	XRequestId           = "X-Request-Id"
+	Traceparent          = "Traceparent"
 )
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+type AccessLogOption func(a *AccessLog)
+
+// WithAccessLogSkip skips logging of requests for which skip returns true.
+func WithAccessLogSkip(skip func(r *http.Request) bool) AccessLogOption {
+	return func(a *AccessLog) {
+		a.skip = append(a.skip, skip)
+	}
+}
+
+// WithAccessLogSkipPaths skips logging of requests to the given paths, e.g. health checks.
+func WithAccessLogSkipPaths(paths ...string) AccessLogOption {
+	return WithAccessLogSkip(func(r *http.Request) bool {
+		return slices.Contains(paths, r.URL.Path)
+	})
+}
+
+// WithAccessLogLevel sets the level for responses of a status class, 4 for 4xx and so on.
+func WithAccessLogLevel(class int, level slog.Level) AccessLogOption {
+	return func(a *AccessLog) {
+		a.levels[class] = level
+	}
+}
+
+// WithAccessLogRedaction sets the policy used to mask headers and query, instead of the one set with SetRedactionPolicy.
+func WithAccessLogRedaction(p *RedactionPolicy) AccessLogOption {
+	return func(a *AccessLog) {
+		a.policy = p
+	}
+}
+
+type AccessLog struct {
+	logger *slog.Logger
+	skip   []func(r *http.Request) bool
+	levels map[int]slog.Level
+	policy *RedactionPolicy
+}
+
+func NewAccessLog(l *slog.Logger, opts ...AccessLogOption) *AccessLog {
+	a := &AccessLog{
+		logger: l,
+		levels: map[int]slog.Level{1: slog.LevelInfo, 2: slog.LevelInfo, 3: slog.LevelInfo, 4: slog.LevelWarn, 5: slog.LevelError},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+func (a *AccessLog) HandlerFunc(handlerFunc http.HandlerFunc) http.Handler {
+	return a.Handler(handlerFunc)
+}
+
+// Handler logs one ECS shaped record per request after the response has been written, with the
+// request fields of GetSlogAttrFromRequest and WithECSFields. If ContextMiddleware wraps the
+// handler, the request-scoped logger is used instead, without repeating the fields it carries.
+func (a *AccessLog) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, skip := range a.skip {
+			if skip(r) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		rw := NewResponseWriter(w)
+		handler.ServeHTTP(rw, r)
+		duration := rw.Duration()
+
+		status := rw.Status()
+		level, ok := a.levels[status/100]
+		if !ok {
+			level = slog.LevelInfo
+		}
+
+		requestOpts := []RequestAttrOption{WithECSFields()}
+		if a.policy != nil {
+			requestOpts = append(requestOpts, WithRequestRedaction(a.policy))
+		}
+		l := a.logger
+		var scoped []slog.Attr
+		if s, ok := r.Context().Value(contextKey{}).(*scope); ok {
+			l = FromContext(r.Context())
+			scoped = s.attrs
+		}
+
+		var attrs []slog.Attr
+		for _, attr := range flattenAttr(nil, "", GetSlogAttrFromRequest(r, requestOpts...)) {
+			// The request-scoped logger already carries these.
+			if !slices.ContainsFunc(scoped, func(s slog.Attr) bool { return s.Key == attr.Key }) {
+				attrs = append(attrs, attr)
+			}
+		}
+		attrs = append(attrs,
+			slog.Int("http.response.status_code", status),
+			slog.Int64("http.response.body.bytes", rw.BytesWritten()),
+			slog.Int64("event.duration", duration.Nanoseconds()),
+		)
+
+		l.LogAttrs(r.Context(), level, fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, status), attrs...)
+	})
+}
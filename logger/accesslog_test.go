@@ -0,0 +1,84 @@
+package logger_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Run("logs ecs fields", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		a := logger.NewAccessLog(l)
+		h := a.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/orders?id=1&access_token=abc&Password=x", nil)
+		req.Header.Set(headers.RemoteUser, "alice")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "POST /orders 201", record["msg"])
+		assert.Equal(t, "POST", record["http.request.method"])
+		assert.Equal(t, "/orders", record["url.path"])
+		assert.Equal(t, "id=1&access_token=<masked>&Password=<masked>", record["url.query"])
+		assert.InDelta(t, 201, record["http.response.status_code"], 0)
+		assert.InDelta(t, 7, record["http.response.body.bytes"], 0)
+		assert.Contains(t, record, "event.duration")
+		assert.Equal(t, "192.0.2.1", record["client.ip"])
+		assert.Equal(t, "alice", record["user.name"])
+		assert.Equal(t, "1.1", record["http.version"])
+		assert.Equal(t, "alice", record["http.request.headers.remote-user"])
+	})
+
+	t.Run("picks level by status class", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		a := logger.NewAccessLog(l, logger.WithAccessLogLevel(4, slog.LevelInfo))
+
+		for status, level := range map[int]string{http.StatusNotFound: "INFO", http.StatusConflict: "INFO", http.StatusBadGateway: "ERROR"} {
+			h := a.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(status) })
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, level, decodeLine(t, buf)["level"])
+		}
+	})
+
+	t.Run("skips configured requests", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		a := logger.NewAccessLog(l,
+			logger.WithAccessLogSkipPaths("/healthz"),
+			logger.WithAccessLogSkip(func(r *http.Request) bool { return r.Method == http.MethodOptions }),
+		)
+		h := a.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { /* no-op */ })
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, "/orders", nil))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("uses request scoped logger", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		a := logger.NewAccessLog(l)
+		h := logger.ContextMiddleware(l, a.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.With(r.Context(), "order.id", "o-1")
+		}))
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+		for _, key := range []string{"http.request.id", "client.ip", "http.request.method", "url.path"} {
+			assert.Equal(t, 1, strings.Count(buf.String(), `"`+key+`"`), key)
+		}
+		record := decodeLine(t, buf)
+		assert.Equal(t, "o-1", record["order.id"])
+		assert.Equal(t, "/orders", record["url.path"])
+		assert.InDelta(t, 200, record["http.response.status_code"], 0)
+	})
+}
@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what AsyncHandler does with a record when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for the buffer to have room, slowing down the logging goroutine.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered record to make room.
+	OverflowDropOldest
+	// OverflowDropNewest discards the record being logged.
+	OverflowDropNewest
+)
+
+const DefaultAsyncBufferSize = 1024
+
+type AsyncOptions struct {
+	// BufferSize defaults to DefaultAsyncBufferSize.
+	BufferSize int
+	Overflow   OverflowPolicy
+}
+
+type asyncEntry struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+type asyncQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	entries  []asyncEntry
+	head     int
+	count    int
+	inFlight bool
+	// waiters are closed by the worker once the buffer is empty, see Flush.
+	waiters  map[chan struct{}]struct{}
+	closed   bool
+	stopped  chan struct{}
+	overflow OverflowPolicy
+	dropped  atomic.Uint64
+}
+
+// AsyncHandler writes records to the wrapped handler from a background goroutine, so logging
+// does not block on slow writers. Handlers derived through WithAttrs and WithGroup share the buffer.
+type AsyncHandler struct {
+	queue   *asyncQueue
+	handler slog.Handler
+}
+
+// Interface guard
+var _ slog.Handler = (*AsyncHandler)(nil)
+
+func NewAsyncHandler(h slog.Handler, opts AsyncOptions) *AsyncHandler {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultAsyncBufferSize
+	}
+	q := &asyncQueue{
+		entries:  make([]asyncEntry, opts.BufferSize),
+		waiters:  map[chan struct{}]struct{}{},
+		stopped:  make(chan struct{}),
+		overflow: opts.Overflow,
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+
+	go q.run()
+
+	return &AsyncHandler{queue: q, handler: h}
+}
+
+func (a *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return a.handler.Enabled(ctx, level)
+}
+
+// Handle buffers a copy of r with its attributes resolved, so LogValuers see the values of the
+// time of logging. Errors of the wrapped handler are not reported. After Close, records are
+// written directly to the wrapped handler.
+func (a *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	resolved := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		resolved.AddAttrs(resolveAttr(attr))
+		return true
+	})
+
+	if !a.queue.push(asyncEntry{ctx: context.WithoutCancel(ctx), handler: a.handler, record: resolved}) {
+		return a.handler.Handle(ctx, resolved)
+	}
+	return nil
+}
+
+func (a *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{queue: a.queue, handler: a.handler.WithAttrs(attrs)}
+}
+
+func (a *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{queue: a.queue, handler: a.handler.WithGroup(name)}
+}
+
+// Dropped returns the number of records discarded because the buffer was full.
+func (a *AsyncHandler) Dropped() uint64 {
+	return a.queue.dropped.Load()
+}
+
+// Flush waits until the buffered records are written or ctx is done.
+func (a *AsyncHandler) Flush(ctx context.Context) error {
+	q := a.queue
+	q.mu.Lock()
+	if q.count == 0 && !q.inFlight {
+		q.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	q.waiters[done] = struct{}{}
+	q.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		delete(q.waiters, done)
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Close writes the buffered records and stops the background goroutine, or returns when ctx is
+// done, leaving the goroutine to stop once the buffer is empty.
+func (a *AsyncHandler) Close(ctx context.Context) error {
+	q := a.queue
+	q.mu.Lock()
+	q.closed = true
+	q.notEmpty.Signal()
+	q.mu.Unlock()
+
+	select {
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// push buffers e and reports whether it was, which it is not once the worker has stopped.
+func (q *asyncQueue) push(e asyncEntry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopping() {
+		return false
+	}
+	if q.count == len(q.entries) {
+		switch q.overflow {
+		case OverflowDropNewest:
+			q.dropped.Add(1)
+			return true
+		case OverflowDropOldest:
+			q.entries[q.head] = asyncEntry{}
+			q.head = (q.head + 1) % len(q.entries)
+			q.count--
+			q.dropped.Add(1)
+		default:
+			for q.count == len(q.entries) {
+				q.notFull.Wait()
+			}
+			// The worker may have emptied the buffer and stopped while waiting.
+			if q.stopping() {
+				return false
+			}
+		}
+	}
+
+	q.entries[(q.head+q.count)%len(q.entries)] = e
+	q.count++
+	q.notEmpty.Signal()
+	return true
+}
+
+// stopping reports whether the worker has stopped or stops without taking another record.
+func (q *asyncQueue) stopping() bool {
+	return q.closed && q.count == 0 && !q.inFlight
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.stopped)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for q.count == 0 {
+			q.inFlight = false
+			for done := range q.waiters {
+				close(done)
+				delete(q.waiters, done)
+			}
+			if q.closed {
+				return
+			}
+			q.notEmpty.Wait()
+		}
+
+		e := q.entries[q.head]
+		q.entries[q.head] = asyncEntry{}
+		q.head = (q.head + 1) % len(q.entries)
+		q.count--
+		q.inFlight = true
+		q.notFull.Signal()
+		q.mu.Unlock()
+
+		_ = e.handler.Handle(e.ctx, e.record)
+
+		q.mu.Lock()
+	}
+}
+
+func resolveAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		resolved := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			resolved[i] = resolveAttr(ga)
+		}
+		a.Value = slog.GroupValue(resolved...)
+	}
+	return a
+}
@@ -0,0 +1,178 @@
+package logger_test
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedHandler signals entering Handle and blocks records until the gate is closed.
+type gatedHandler struct {
+	slog.Handler
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func newGatedHandler(h slog.Handler) gatedHandler {
+	return gatedHandler{Handler: h, entered: make(chan struct{}, 1), gate: make(chan struct{})}
+}
+
+func (g gatedHandler) Handle(ctx context.Context, r slog.Record) error {
+	select {
+	case g.entered <- struct{}{}:
+	default:
+	}
+	<-g.gate
+	return g.Handler.Handle(ctx, r)
+}
+
+func messages(records []logtest.Record) []string {
+	result := make([]string, len(records))
+	for i, r := range records {
+		result[i] = r.Message
+	}
+	return result
+}
+
+func TestAsyncHandler(t *testing.T) {
+	// fill returns a handler with a buffer of two full records, while the worker is stuck on a third.
+	fill := func(t *testing.T, overflow logger.OverflowPolicy) (*logger.AsyncHandler, *logtest.Handler, gatedHandler) {
+		t.Helper()
+		rec := logtest.New()
+		gated := newGatedHandler(rec)
+		h := logger.NewAsyncHandler(gated, logger.AsyncOptions{BufferSize: 2, Overflow: overflow})
+		l := slog.New(h)
+
+		l.Info("0")
+		<-gated.entered
+		l.Info("1")
+		l.Info("2")
+		return h, rec, gated
+	}
+
+	t.Run("writes records in order and flushes", func(t *testing.T) {
+		rec := logtest.New()
+		h := logger.NewAsyncHandler(rec, logger.AsyncOptions{})
+		l := slog.New(h).With("service", "billing")
+
+		for i := range 100 {
+			l.Info(strconv.Itoa(i), "n", i)
+		}
+
+		require.NoError(t, h.Flush(context.Background()))
+		records := rec.Records()
+		require.Len(t, records, 100)
+		assert.Equal(t, "99", records[99].Message)
+		rec.AssertLogged(t, slog.LevelInfo, "42", "n", 42, "service", "billing")
+		assert.Zero(t, h.Dropped())
+	})
+
+	t.Run("drops the newest records when full", func(t *testing.T) {
+		h, rec, gated := fill(t, logger.OverflowDropNewest)
+		slog.New(h).Info("3")
+		close(gated.gate)
+
+		require.NoError(t, h.Flush(context.Background()))
+		assert.Equal(t, []string{"0", "1", "2"}, messages(rec.Records()))
+		assert.Equal(t, uint64(1), h.Dropped())
+	})
+
+	t.Run("drops the oldest records when full", func(t *testing.T) {
+		h, rec, gated := fill(t, logger.OverflowDropOldest)
+		slog.New(h).Info("3")
+		close(gated.gate)
+
+		require.NoError(t, h.Flush(context.Background()))
+		assert.Equal(t, []string{"0", "2", "3"}, messages(rec.Records()))
+		assert.Equal(t, uint64(1), h.Dropped())
+	})
+
+	t.Run("blocks when full", func(t *testing.T) {
+		rec := logtest.New()
+		gated := newGatedHandler(rec)
+		h := logger.NewAsyncHandler(gated, logger.AsyncOptions{BufferSize: 1})
+		l := slog.New(h)
+
+		l.Info("0")
+		<-gated.entered
+		l.Info("1")
+		logged := make(chan struct{})
+		go func() {
+			l.Info("2")
+			close(logged)
+		}()
+
+		select {
+		case <-logged:
+			t.Fatal("logging did not block")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, h.Flush(ctx), context.DeadlineExceeded)
+
+		close(gated.gate)
+		<-logged
+		require.NoError(t, h.Flush(context.Background()))
+		assert.Equal(t, []string{"0", "1", "2"}, messages(rec.Records()))
+		assert.Zero(t, h.Dropped())
+	})
+
+	t.Run("resolves attributes when logged", func(t *testing.T) {
+		rec := logtest.New()
+		gated := newGatedHandler(rec)
+		h := logger.NewAsyncHandler(gated, logger.AsyncOptions{})
+
+		order := &mutableOrder{status: "pending"}
+		slog.New(h).Info("order", "order", order, slog.Group("nested", "order", order))
+		order.status = "paid"
+		close(gated.gate)
+
+		require.NoError(t, h.Flush(context.Background()))
+		rec.AssertLogged(t, slog.LevelInfo, "order", "order", "pending", "nested.order", "pending")
+	})
+
+	t.Run("writes the buffer on close and logs directly afterwards", func(t *testing.T) {
+		h, rec, gated := fill(t, logger.OverflowBlock)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, h.Close(ctx), context.DeadlineExceeded)
+
+		close(gated.gate)
+		require.NoError(t, h.Close(context.Background()))
+		assert.Equal(t, []string{"0", "1", "2"}, messages(rec.Records()))
+
+		slog.New(h).Info("3")
+		assert.Equal(t, []string{"0", "1", "2", "3"}, messages(rec.Records()))
+		require.NoError(t, h.Flush(context.Background()))
+	})
+
+	t.Run("flushes again after a timed out flush", func(t *testing.T) {
+		h, rec, gated := fill(t, logger.OverflowBlock)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, h.Flush(ctx), context.DeadlineExceeded)
+
+		close(gated.gate)
+		require.NoError(t, h.Flush(context.Background()))
+		assert.Len(t, rec.Records(), 3)
+	})
+}
+
+type mutableOrder struct {
+	status string
+}
+
+func (o *mutableOrder) LogValue() slog.Value {
+	return slog.StringValue(o.status)
+}
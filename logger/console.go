@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiFaint  = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+)
+
+// consoleHandler writes colored single line records for local development:
+// 15:04:05.000 INF message key=value group.key=value
+type consoleHandler struct {
+	mu        *sync.Mutex
+	w         io.Writer
+	level     slog.Leveler
+	addSource bool
+	attrs     string
+	groups    string
+}
+
+func newConsoleHandler(w io.Writer, level slog.Leveler, addSource bool) *consoleHandler {
+	return &consoleHandler{mu: &sync.Mutex{}, w: w, level: level, addSource: addSource}
+}
+
+func (c *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= c.level.Level()
+}
+
+func (c *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	sb.WriteString(ansiFaint + r.Time.Format("15:04:05.000") + ansiReset + " ")
+	sb.WriteString(levelColor(r.Level) + levelAbbreviation(r.Level) + ansiReset + " ")
+	sb.WriteString(r.Message)
+	if c.addSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		sb.WriteString(" " + ansiFaint + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line) + ansiReset)
+	}
+	sb.WriteString(c.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendConsoleAttr(&sb, c.groups, a)
+		return true
+	})
+	sb.WriteString("\n")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.w, sb.String())
+	return err
+}
+
+func (c *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var sb strings.Builder
+	for _, a := range attrs {
+		appendConsoleAttr(&sb, c.groups, a)
+	}
+	next := *c
+	next.attrs += sb.String()
+	return &next
+}
+
+func (c *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return c
+	}
+	next := *c
+	next.groups += name + "."
+	return &next
+}
+
+func appendConsoleAttr(sb *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendConsoleAttr(sb, prefix, ga)
+		}
+		return
+	}
+
+	sb.WriteString(" " + ansiFaint + prefix + a.Key + "=" + ansiReset)
+	var value string
+	if a.Value.Kind() == slog.KindTime {
+		value = a.Value.Time().Format(time.RFC3339Nano)
+	} else {
+		value = fmt.Sprint(a.Value.Any())
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) || r == '"' || r == '=' }) >= 0 || value == "" {
+		value = strconv.Quote(value)
+	}
+	sb.WriteString(value)
+}
+
+func levelAbbreviation(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "ERR"
+	case level >= slog.LevelWarn:
+		return "WRN"
+	case level >= slog.LevelInfo:
+		return "INF"
+	}
+	return "DBG"
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return ansiRed
+	case level >= slog.LevelWarn:
+		return ansiYellow
+	case level >= slog.LevelInfo:
+		return ansiGreen
+	}
+	return ansiBlue
+}
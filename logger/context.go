@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/cego/go-lib/v2/headers"
+)
+
+type contextKey struct{}
+
+// scope is shared by everything holding the request context, so attributes added by With are
+// visible to handlers further up the chain as well.
+type scope struct {
+	mu        sync.RWMutex
+	logger    *slog.Logger
+	requestID string
+	// attrs are the request attributes the logger was created with.
+	attrs []slog.Attr
+}
+
+// WithContext returns a copy of ctx carrying l, retrieve it with FromContext.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &scope{logger: l})
+}
+
+// FromContext returns the logger stored in ctx, or slog.Default() if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.logger
+	}
+	return slog.Default()
+}
+
+// With adds attributes to the logger stored in ctx. It is a no-op if ctx carries no logger.
+func With(ctx context.Context, args ...any) {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.logger = s.logger.With(args...)
+	}
+}
+
+// RequestID returns the request id assigned by ContextMiddleware, or "" if there is none. Ids taken
+// from X-Request-Id are at most 128 characters of letters, digits, '.', '_' and '-'.
+func RequestID(ctx context.Context) string {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		return s.requestID
+	}
+	return ""
+}
+
+// ContextMiddleware stores a request-scoped logger in the request context, pre-populated with
+// request id, client ip, method and path. The request id is taken from the X-Request-Id header if
+// it is valid, see RequestID, or generated, and echoed in the response. The request headers are
+// not modified. A valid traceparent header is stored as TraceContext
+// with a new TransactionID.
+func ContextMiddleware(l *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(headers.XRequestId)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(headers.XRequestId, requestID)
+
+		attrs := []slog.Attr{
+			slog.String("http.request.id", requestID),
+			slog.String("client.ip", clientIP(r)),
+			slog.String("http.request.method", r.Method),
+			slog.String("url.path", r.URL.Path),
+		}
+		s := &scope{requestID: requestID, logger: slog.New(l.Handler().WithAttrs(attrs)), attrs: attrs}
+		ctx := context.WithValue(r.Context(), contextKey{}, s)
+		if tc, err := ParseTraceparent(r.Header.Get(headers.Traceparent)); err == nil {
+			tc.TransactionID = newSpanID()
+			ctx = ContextWithTrace(ctx, tc)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+const maxRequestIDLength = 128
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func clientIP(req *http.Request) string {
+	ip, _, _ := net.SplitHostPort(req.RemoteAddr)
+	return ip
+}
@@ -0,0 +1,127 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBufferLogger() (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	return slog.New(slog.NewJSONHandler(buf, nil)), buf
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	buf.Reset()
+	return record
+}
+
+func TestContext(t *testing.T) {
+	t.Run("falls back to the default logger", func(t *testing.T) {
+		assert.Same(t, slog.Default(), logger.FromContext(context.Background()))
+	})
+
+	t.Run("stores logger and adds attributes in place", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		ctx := logger.WithContext(context.Background(), l)
+
+		logger.With(ctx, "order.id", 42)
+		logger.FromContext(ctx).Info("hello")
+
+		record := decodeLine(t, buf)
+		assert.InDelta(t, 42, record["order.id"], 0)
+	})
+
+	t.Run("middleware adds request attributes", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		var requestID string
+		h := logger.ContextMiddleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = logger.RequestID(r.Context())
+			logger.With(r.Context(), "user.name", "alice")
+			logger.FromContext(r.Context()).Info("handled")
+		}))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders?id=1", nil))
+
+		record := decodeLine(t, buf)
+		assert.Len(t, requestID, 32)
+		assert.Equal(t, requestID, rec.Header().Get(headers.XRequestId))
+		assert.Equal(t, requestID, record["http.request.id"])
+		assert.Equal(t, "192.0.2.1", record["client.ip"])
+		assert.Equal(t, "POST", record["http.request.method"])
+		assert.Equal(t, "/orders", record["url.path"])
+		assert.Equal(t, "alice", record["user.name"])
+	})
+
+	t.Run("middleware keeps incoming request id", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		h := logger.ContextMiddleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.FromContext(r.Context()).Info("handled")
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(headers.XRequestId, "abc")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "abc", decodeLine(t, buf)["http.request.id"])
+	})
+
+	t.Run("middleware replaces invalid request ids without touching the request", func(t *testing.T) {
+		for name, incoming := range map[string]string{
+			"empty":      "",
+			"too long":   strings.Repeat("a", 129),
+			"whitespace": "abc def",
+			"newline":    "abc\n{\"forged\":true}",
+			"non-ascii":  "abcæ",
+		} {
+			t.Run(name, func(t *testing.T) {
+				l, buf := newBufferLogger()
+				var seen string
+				h := logger.ContextMiddleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					seen = r.Header.Get(headers.XRequestId)
+					logger.FromContext(r.Context()).Info("handled")
+				}))
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				if incoming != "" {
+					req.Header.Set(headers.XRequestId, incoming)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+
+				requestID := rec.Header().Get(headers.XRequestId)
+				assert.Len(t, requestID, 32)
+				assert.Equal(t, requestID, decodeLine(t, buf)["http.request.id"])
+				assert.Equal(t, incoming, seen)
+			})
+		}
+	})
+
+	t.Run("middleware accepts the longest valid request id", func(t *testing.T) {
+		l, buf := newBufferLogger()
+		h := logger.ContextMiddleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.FromContext(r.Context()).Info("handled")
+		}))
+
+		requestID := strings.Repeat("a", 123) + "-B.9_"
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(headers.XRequestId, requestID)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, requestID, decodeLine(t, buf)["http.request.id"])
+	})
+}
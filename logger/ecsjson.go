@@ -0,0 +1,441 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ecsHandler writes the output of slog.JSONHandler with replaceAttr byte for byte, but encodes the
+// built-in fields directly and reuses buffers. It follows the structure of the slog handler, so
+// groups, empty attributes and encoding errors come out the same.
+type ecsHandler struct {
+	w           io.Writer
+	mu          *sync.Mutex
+	level       slog.Leveler
+	addSource   bool
+	replaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// preformatted holds the attributes of WithAttrs, including the groups opened for them.
+	preformatted []byte
+	groups       []string
+	nOpenGroups  int
+}
+
+// Interface guard
+var _ slog.Handler = (*ecsHandler)(nil)
+
+func newECSHandler(w io.Writer, level slog.Leveler, addSource bool, replaceAttr func([]string, slog.Attr) slog.Attr) *ecsHandler {
+	return &ecsHandler{w: w, mu: &sync.Mutex{}, level: level, addSource: addSource, replaceAttr: replaceAttr}
+}
+
+func (h *ecsHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ecsHandler) Handle(_ context.Context, r slog.Record) error {
+	s := h.newState()
+	defer s.free()
+
+	s.buf = append(s.buf, '{')
+	if !r.Time.IsZero() {
+		s.buf = append(s.buf, `"@timestamp":"`...)
+		s.buf = r.Time.UTC().AppendFormat(s.buf, time.RFC3339Nano)
+		s.buf = append(s.buf, '"')
+		s.sep = true
+	}
+	if s.sep {
+		s.buf = append(s.buf, ',')
+	}
+	s.buf = append(s.buf, `"log.level":`...)
+	s.appendString(r.Level.String())
+	s.sep = true
+
+	if h.addSource {
+		// Built-in fields are not in the groups of WithGroup.
+		groups := s.groups
+		s.groups = s.groups[:0]
+		src := r.Source()
+		if src == nil {
+			src = &slog.Source{}
+		}
+		s.appendAttr(slog.Any(slog.SourceKey, src))
+		s.groups = append(groups[:0], h.groups[:h.nOpenGroups]...)
+	}
+
+	s.buf = append(s.buf, `,"message":`...)
+	s.appendString(r.Message)
+
+	if len(h.preformatted) > 0 {
+		s.buf = append(s.buf, ',')
+		s.buf = append(s.buf, h.preformatted...)
+		s.sep = h.preformatted[len(h.preformatted)-1] != '{'
+	}
+	// Groups of WithGroup are only written when the record has attributes.
+	nOpenGroups := h.nOpenGroups
+	if r.NumAttrs() > 0 {
+		pos := len(s.buf)
+		s.openGroups()
+		nOpenGroups = len(h.groups)
+		empty := true
+		r.Attrs(func(a slog.Attr) bool {
+			if s.appendAttr(a) {
+				empty = false
+			}
+			return true
+		})
+		if empty {
+			s.buf = s.buf[:pos]
+			nOpenGroups = h.nOpenGroups
+		}
+	}
+	for range nOpenGroups {
+		s.buf = append(s.buf, '}')
+	}
+	s.buf = append(s.buf, '}', '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(s.buf)
+	return err
+}
+
+func (h *ecsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	empty := 0
+	for _, a := range attrs {
+		if a.Value.Kind() == slog.KindGroup && len(a.Value.Group()) == 0 {
+			empty++
+		}
+	}
+	if empty == len(attrs) {
+		return h
+	}
+
+	h2 := h.clone()
+	s := h2.newState()
+	defer s.free()
+	s.buf = append(s.buf, h2.preformatted...)
+	s.sep = len(h2.preformatted) > 0 && h2.preformatted[len(h2.preformatted)-1] != '{'
+	pos := len(s.buf)
+	s.openGroups()
+	if !s.appendAttrs(attrs) {
+		s.buf = s.buf[:pos]
+	} else {
+		h2.nOpenGroups = len(h2.groups)
+	}
+	h2.preformatted = bytes.Clone(s.buf)
+	return h2
+}
+
+func (h *ecsHandler) WithGroup(name string) slog.Handler {
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+func (h *ecsHandler) clone() *ecsHandler {
+	h2 := *h
+	h2.preformatted = h.preformatted[:len(h.preformatted):len(h.preformatted)]
+	h2.groups = h.groups[:len(h.groups):len(h.groups)]
+	return &h2
+}
+
+// ecsStateMaxBuffer keeps the buffers of a few very large records from staying in the pool.
+const ecsStateMaxBuffer = 16 << 10
+
+var ecsStatePool = sync.Pool{New: func() any {
+	return &ecsState{buf: make([]byte, 0, 1024), groups: make([]string, 0, 8)}
+}}
+
+// ecsState holds the buffer and the open groups, passed to replaceAttr, while encoding a record.
+type ecsState struct {
+	h      *ecsHandler
+	buf    []byte
+	sep    bool
+	groups []string
+}
+
+func (h *ecsHandler) newState() *ecsState {
+	s := ecsStatePool.Get().(*ecsState)
+	s.h = h
+	s.groups = append(s.groups, h.groups[:h.nOpenGroups]...)
+	return s
+}
+
+func (s *ecsState) free() {
+	if cap(s.buf) > ecsStateMaxBuffer {
+		return
+	}
+	s.h = nil
+	s.buf = s.buf[:0]
+	s.sep = false
+	clear(s.groups)
+	s.groups = s.groups[:0]
+	ecsStatePool.Put(s)
+}
+
+func (s *ecsState) openGroups() {
+	for _, name := range s.h.groups[s.h.nOpenGroups:] {
+		s.openGroup(name)
+	}
+}
+
+func (s *ecsState) openGroup(name string) {
+	s.appendKey(name)
+	s.buf = append(s.buf, '{')
+	s.sep = false
+	s.groups = append(s.groups, name)
+}
+
+func (s *ecsState) closeGroup() {
+	s.buf = append(s.buf, '}')
+	s.sep = true
+	s.groups = s.groups[:len(s.groups)-1]
+}
+
+func (s *ecsState) appendAttrs(attrs []slog.Attr) bool {
+	nonEmpty := false
+	for _, a := range attrs {
+		if s.appendAttr(a) {
+			nonEmpty = true
+		}
+	}
+	return nonEmpty
+}
+
+// appendAttr appends a and reports whether anything was appended.
+func (s *ecsState) appendAttr(a slog.Attr) bool {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		a = s.h.replaceAttr(s.groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Key == "" && a.Value.Kind() == slog.KindAny && a.Value.Any() == nil {
+		return false
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			if src == nil || *src == (slog.Source{}) {
+				return false
+			}
+			a.Value = sourceGroup(src)
+		}
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		s.appendKey(a.Key)
+		s.appendValue(a.Value)
+		return true
+	}
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return true
+	}
+	pos := len(s.buf)
+	if a.Key != "" {
+		s.openGroup(a.Key)
+	}
+	if !s.appendAttrs(attrs) {
+		s.buf = s.buf[:pos]
+		return false
+	}
+	if a.Key != "" {
+		s.closeGroup()
+	}
+	return true
+}
+
+// sourceGroup is how slog.JSONHandler writes a *slog.Source that is not the built-in one.
+func sourceGroup(src *slog.Source) slog.Value {
+	var attrs []slog.Attr
+	if src.Function != "" {
+		attrs = append(attrs, slog.String("function", src.Function))
+	}
+	if src.File != "" {
+		attrs = append(attrs, slog.String("file", src.File))
+	}
+	if src.Line != 0 {
+		attrs = append(attrs, slog.Int("line", src.Line))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (s *ecsState) appendKey(key string) {
+	if s.sep {
+		s.buf = append(s.buf, ',')
+	}
+	s.appendString(key)
+	s.buf = append(s.buf, ':')
+	s.sep = true
+}
+
+func (s *ecsState) appendString(str string) {
+	s.buf = append(s.buf, '"')
+	s.buf = appendEscapedJSONString(s.buf, str)
+	s.buf = append(s.buf, '"')
+}
+
+func (s *ecsState) appendValue(v slog.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			// Like slog, a nil pointer error or marshaler is most likely missing a nil check.
+			if rv := reflect.ValueOf(v.Any()); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				s.appendString("<nil>")
+				return
+			}
+			s.appendString(fmt.Sprintf("!PANIC: %v", r))
+		}
+	}()
+
+	if err := s.appendJSONValue(v); err != nil {
+		s.appendString(fmt.Sprintf("!ERROR:%v", err))
+	}
+}
+
+func (s *ecsState) appendJSONValue(v slog.Value) error {
+	switch v.Kind() {
+	case slog.KindString:
+		s.appendString(v.String())
+	case slog.KindInt64:
+		s.buf = strconv.AppendInt(s.buf, v.Int64(), 10)
+	case slog.KindUint64:
+		s.buf = strconv.AppendUint(s.buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return s.appendFloat(v.Float64())
+	case slog.KindBool:
+		s.buf = strconv.AppendBool(s.buf, v.Bool())
+	case slog.KindDuration:
+		s.buf = strconv.AppendInt(s.buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		t := v.Time()
+		if y := t.Year(); y < 0 || y >= 10000 {
+			return errors.New("time.Time year outside of range [0,9999]")
+		}
+		s.buf = append(s.buf, '"')
+		s.buf = t.AppendFormat(s.buf, time.RFC3339Nano)
+		s.buf = append(s.buf, '"')
+	default:
+		a := v.Any()
+		_, marshaler := a.(json.Marshaler)
+		if err, ok := a.(error); ok && !marshaler {
+			s.appendString(err.Error())
+			return nil
+		}
+		return s.appendJSONMarshal(a)
+	}
+	return nil
+}
+
+// appendFloat formats f like encoding/json, which slog.JSONHandler uses for floats.
+func (s *ecsState) appendFloat(f float64) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return s.appendJSONMarshal(f)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	s.buf = strconv.AppendFloat(s.buf, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if n := len(s.buf); n >= 4 && s.buf[n-4] == 'e' && s.buf[n-3] == '-' && s.buf[n-2] == '0' {
+			s.buf[n-2] = s.buf[n-1]
+			s.buf = s.buf[:n-1]
+		}
+	}
+	return nil
+}
+
+var ecsEncoderPool = sync.Pool{New: func() any {
+	e := &ecsEncoder{}
+	e.enc = json.NewEncoder(&e.buf)
+	e.enc.SetEscapeHTML(false)
+	return e
+}}
+
+type ecsEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+func (s *ecsState) appendJSONMarshal(v any) error {
+	e := ecsEncoderPool.Get().(*ecsEncoder)
+	defer func() {
+		if e.buf.Cap() <= ecsStateMaxBuffer {
+			e.buf.Reset()
+			ecsEncoderPool.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	s.buf = append(s.buf, bytes.TrimSuffix(e.buf.Bytes(), []byte{'\n'})...)
+	return nil
+}
+
+// invalidUTF8 is what encoding/json writes for invalid UTF-8, an escaped or a literal U+FFFD
+// depending on the JSON experiment of the toolchain.
+var invalidUTF8 = func() string {
+	b, _ := json.Marshal("\xff")
+	return string(b[1 : len(b)-1])
+}()
+
+// appendEscapedJSONString appends str escaped like slog.JSONHandler, which is encoding/json
+// without HTML escaping.
+func appendEscapedJSONString(buf []byte, str string) []byte {
+	const hex = "0123456789abcdef"
+	start := 0
+	for i := 0; i < len(str); {
+		if b := str[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, str[start:i]...)
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(str[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, str[start:i]...)
+			buf = append(buf, invalidUTF8...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON, but escaped by encoding/json for JSONP.
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, str[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(buf, str[start:]...)
+}
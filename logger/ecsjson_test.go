@@ -0,0 +1,152 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/netip"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ecsUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (u ecsUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", u.ID), slog.String("name", u.Name))
+}
+
+type nilError struct{ message string }
+
+func (e *nilError) Error() string { return e.message }
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("cannot marshal") }
+
+func callerPC() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	return pcs[0]
+}
+
+func TestECSHandler(t *testing.T) {
+	at := time.Date(2024, 5, 17, 10, 4, 5, 120300000, time.FixedZone("CEST", 2*60*60))
+	attrs := []slog.Attr{
+		slog.String("string", "quotes \" backslash \\ html <a href=\"x\">&</a> newline \n tab \t control \x01 invalid \xff separator \u2028 \u2029 unicode æøå"),
+		slog.Int("int", -42),
+		slog.Uint64("uint", math.MaxUint64),
+		slog.Float64("float", 0.1),
+		slog.Float64("large", 1e21),
+		slog.Float64("small", 1.5e-7),
+		slog.Float64("zero", math.Copysign(0, -1)),
+		slog.Float64("nan", math.NaN()),
+		slog.Bool("bool", true),
+		slog.Duration("duration", 1500*time.Millisecond),
+		slog.Time("at", at),
+		slog.Time("far", time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)),
+		slog.Any("error", errors.New("boom")),
+		slog.Any("nil_error", (*nilError)(nil)),
+		slog.Any("marshaler", failingMarshaler{}),
+		slog.Any("addr", netip.MustParseAddr("10.0.0.1")),
+		slog.Any("map", map[string]any{"b": 1, "a": "<x>"}),
+		slog.Any("raw", json.RawMessage(`{"a": 1}`)),
+		slog.Any("user", ecsUser{ID: 1, Name: "jane"}),
+		slog.Any("nil", nil),
+		slog.Any("", nil),
+		slog.Group("empty"),
+		slog.Group("", slog.Int("inlined", 1)),
+		slog.Group("nested", slog.Group("deeper", slog.String("key", "value")), slog.Group("deleted", slog.Any("", nil))),
+		slog.Any("origin", &slog.Source{Function: "main.main", File: "main.go", Line: 3}),
+		slog.Any("empty_origin", &slog.Source{}),
+		slog.String("msg", "user message"),
+		slog.String("level", "user level"),
+		slog.Time("time", at),
+		slog.Any("source", &slog.Source{File: "user.go", Line: 7}),
+	}
+
+	cases := map[string]func(h slog.Handler) slog.Handler{
+		"plain":       func(h slog.Handler) slog.Handler { return h },
+		"attrs":       func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs[:4]) },
+		"group":       func(h slog.Handler) slog.Handler { return h.WithGroup("http") },
+		"empty group": func(h slog.Handler) slog.Handler { return h.WithGroup("") },
+		"group and attrs": func(h slog.Handler) slog.Handler {
+			return h.WithAttrs([]slog.Attr{slog.String("service.name", "billing")}).WithGroup("http").WithAttrs(attrs[10:14]).WithGroup("request")
+		},
+		"empty attrs": func(h slog.Handler) slog.Handler {
+			return h.WithGroup("http").WithAttrs([]slog.Attr{slog.Group("empty"), slog.Any("", nil)})
+		},
+	}
+
+	t.Run("writes the same as slog.JSONHandler", func(t *testing.T) {
+		for _, addSource := range []bool{false, true} {
+			for style, replaceAttr := range map[string]func([]string, slog.Attr) slog.Attr{"nested": logger.ReplaceECSAttr, "dotted": logger.DottedReplaceAttr} {
+				for name, setup := range cases {
+					expected, actual := &bytes.Buffer{}, &bytes.Buffer{}
+					slogHandler := setup(slog.NewJSONHandler(expected, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: addSource, ReplaceAttr: replaceAttr}))
+					ecsHandler := setup(logger.NewECSHandler(actual, slog.LevelDebug, addSource, replaceAttr))
+
+					records := []slog.Record{
+						slog.NewRecord(at, slog.LevelInfo, "with attributes", callerPC()),
+						slog.NewRecord(at, slog.LevelWarn+2, "without attributes", callerPC()),
+						slog.NewRecord(time.Time{}, slog.LevelError, "without time or source", 0),
+						slog.NewRecord(at, slog.LevelDebug, "only empty attributes", 0),
+					}
+					records[0].AddAttrs(attrs...)
+					records[3].AddAttrs(slog.Group("empty"), slog.Group("deleted", slog.Any("", nil)))
+
+					for _, r := range records {
+						require.NoError(t, slogHandler.Handle(context.Background(), r))
+						require.NoError(t, ecsHandler.Handle(context.Background(), r))
+					}
+					assert.Equal(t, expected.String(), actual.String(), "%s, %s, add source %t", name, style, addSource)
+				}
+			}
+		}
+	})
+
+	t.Run("is used by New for json", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger.New(logger.WithWriter(buf), logger.WithAddSource()).Info("hello", "id", 7)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "hello", record["message"])
+		assert.Equal(t, "INFO", record["log.level"])
+		assert.Contains(t, record, "@timestamp")
+		assert.Contains(t, record, "log.origin")
+		assert.InDelta(t, 7, record["id"], 0)
+	})
+}
+
+func BenchmarkECSHandler(b *testing.B) {
+	handlers := map[string]slog.Handler{
+		"ecs":  logger.NewECSHandler(io.Discard, slog.LevelDebug, false, logger.ReplaceECSAttr),
+		"slog": slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: logger.ReplaceECSAttr}),
+	}
+	for _, name := range []string{"ecs", "slog"} {
+		l := slog.New(handlers[name]).With("service.name", "billing").WithGroup("http")
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				l.Info("request handled",
+					slog.String("method", "GET"),
+					slog.Int("status_code", 200),
+					slog.Duration("duration", 12*time.Millisecond),
+					slog.Group("url", slog.String("path", "/payments/42")),
+				)
+			}
+		})
+	}
+}
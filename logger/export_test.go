@@ -0,0 +1,20 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+// Exported for the comparison of the ECS handler with slog.JSONHandler.
+var (
+	NewECSHandler     = newECSHandler
+	ReplaceECSAttr    = replaceECSAttr
+	DottedReplaceAttr = dottedReplaceAttr
+)
+
+// SetNetTimeout sets the dial and write timeout of GELFWriter and SyslogWriter until the test ends.
+func SetNetTimeout(t testing.TB, timeout time.Duration) {
+	previous := netTimeout
+	netTimeout = timeout
+	t.Cleanup(func() { netTimeout = previous })
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+// NewFanoutHandler returns a handler writing each record to every handler enabled for its level,
+// so each handler keeps its own level, e.g. stdout at info and a file at debug.
+func NewFanoutHandler(handlers ...slog.Handler) slog.Handler {
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (f *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f.handlers {
+		if !handlerEnabled(ctx, h, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(f.handlers))
+	for i, h := range f.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (f *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(f.handlers))
+	for i, h := range f.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+type levelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+// NewLevelHandler restricts h to records at or above level, for handlers that were not
+// constructed with a level of their own.
+func NewLevelHandler(level slog.Leveler, h slog.Handler) slog.Handler {
+	return &levelHandler{level: level, handler: h}
+}
+
+func (l *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= l.level.Level() && l.handler.Enabled(ctx, level)
+}
+
+func (l *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return l.handler.Handle(ctx, r)
+}
+
+func (l *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: l.level, handler: l.handler.WithAttrs(attrs)}
+}
+
+func (l *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: l.level, handler: l.handler.WithGroup(name)}
+}
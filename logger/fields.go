@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"runtime"
+	"time"
+	"unicode/utf8"
+)
+
+// truncatedSuffix marks a field cut to make a message fit the size limit of its writer.
+const truncatedSuffix = "...[truncated]"
+
+// fieldsHandler is the end of the handler chain of formats that are not written by a slog
+// handler. It receives dotted keys from a KeyStyleDotted handler and passes them to write.
+type fieldsHandler struct {
+	level     slog.Leveler
+	addSource bool
+	attrs     []slog.Attr
+	write     func(r slog.Record, attrs []slog.Attr) error
+}
+
+// newFieldsHandler composes the handler chain of New for write, so the formats get the same
+// fields: static fields, dotted keys and trace fields.
+func newFieldsHandler(o *options, write func(r slog.Record, attrs []slog.Attr) error) slog.Handler {
+	var h slog.Handler = &fieldsHandler{level: o.level, addSource: o.addSource, write: write}
+	h = NewKeyStyleHandler(h, KeyStyleDotted)
+	if len(o.fields) > 0 {
+		h = h.WithAttrs(o.fields)
+	}
+	return NewTraceHandler(h)
+}
+
+func (f *fieldsHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= f.level.Level()
+}
+
+func (f *fieldsHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(f.attrs)+r.NumAttrs()+3)
+	if f.addSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		attrs = append(attrs,
+			slog.String("log.origin.file.name", frame.File),
+			slog.Int("log.origin.file.line", frame.Line),
+			slog.String("log.origin.function", frame.Function),
+		)
+	}
+	attrs = append(attrs, f.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return f.write(r, attrs)
+}
+
+func (f *fieldsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &fieldsHandler{level: f.level, addSource: f.addSource, attrs: append(f.attrs[:len(f.attrs):len(f.attrs)], attrs...), write: f.write}
+}
+
+// WithGroup is not reached through the KeyStyleDotted handler, which turns groups into key prefixes.
+func (f *fieldsHandler) WithGroup(name string) slog.Handler {
+	return NewKeyStyleHandler(f, KeyStyleDotted).WithGroup(name)
+}
+
+// fieldValue returns v as encoded by slog.JSONHandler.
+func fieldValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().Nanoseconds()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			if _, ok := err.(json.Marshaler); !ok {
+				return err.Error()
+			}
+		}
+	}
+	return v.Any()
+}
+
+// appendJSONField appends "key":value to an open JSON object, without escaping HTML like slog.JSONHandler.
+func appendJSONField(buf *bytes.Buffer, key string, value any) {
+	if buf.Len() > 1 {
+		buf.WriteByte(',')
+	}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(key)
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteByte(':')
+	if err := enc.Encode(value); err != nil {
+		_ = enc.Encode("!ERROR:" + err.Error())
+	}
+	buf.Truncate(buf.Len() - 1)
+}
+
+// severity maps a level onto the syslog severities used by GELF and syslog.
+func severity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// staticField returns the value of a static field set through options, e.g. host.name.
+func staticField(o *options, key string) string {
+	for _, a := range o.fields {
+		if a.Key == key {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
+
+// maxMessageSize returns the size limit of w, 0 if it has none. GELFWriter and SyslogWriter over UDP have one.
+func maxMessageSize(w io.Writer) int {
+	if m, ok := w.(interface{ MaxMessageSize() int }); ok {
+		return m.MaxMessageSize()
+	}
+	return 0
+}
+
+// fitMessage returns encode(long), with long cut and marked as truncated until the message is at
+// most limit bytes. Without a limit, or if cutting long entirely is not enough, it is returned as is.
+func fitMessage(limit int, long string, encode func(long string) []byte) []byte {
+	msg := encode(long)
+	n := len(long)
+	for limit > 0 && len(msg) > limit && n > 0 {
+		// Escaping makes the encoded value at least as long as the cut part.
+		n = max(n-(len(msg)-limit), 0)
+		for n > 0 && !utf8.RuneStart(long[n]) {
+			n--
+		}
+		msg = encode(long[:n] + truncatedSuffix)
+	}
+	return msg
+}
@@ -0,0 +1,248 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// FileOptions configures rotation of a FileWriter. Zero values disable the respective limit.
+type FileOptions struct {
+	// MaxSize rotates the file before a write would make it exceed MaxSize bytes.
+	MaxSize int64
+	// MaxAge rotates the file once it has been written to for longer than MaxAge. The age of a file
+	// that already exists when the FileWriter is created counts from its modification time, as
+	// not every platform records when a file was created.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep, the oldest are removed.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// FileWriter is an io.Writer appending to a file and rotating it to timestamped backups,
+// e.g. app.log is rotated to app-2026-01-02T15-04-05.000000000.log. Rotation happens during
+// Write, so pair it with NewAsyncHandler when compressing large files.
+type FileWriter struct {
+	path   string
+	opts   FileOptions
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// Interface guard
+var _ io.WriteCloser = (*FileWriter)(nil)
+
+func NewFileWriter(path string, opts FileOptions) (*FileWriter, error) {
+	w := &FileWriter{path: path, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the file, rotating it first if needed. If rotation fails, p is written to
+// the current file, the error is returned along with len(p) and rotation is retried on the next
+// Write.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if w.shouldRotate(int64(len(p))) {
+		rotateErr = w.rotate()
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Rotate moves the current file to a backup and opens a new one. If that fails, the current file
+// is kept open.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *FileWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	return w.opts.MaxAge > 0 && time.Since(w.opened) > w.opts.MaxAge
+}
+
+func (w *FileWriter) open() error {
+	f, size, opened, err := openLogFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.file, w.size, w.opened = f, size, opened
+	return nil
+}
+
+// openLogFile opens path for appending, returning its size and when it was first written to.
+func openLogFile(path string) (*os.File, int64, time.Time, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, time.Time{}, err
+	}
+
+	opened := time.Now()
+	if info.Size() > 0 {
+		opened = info.ModTime()
+	}
+	return f, info.Size(), opened, nil
+}
+
+// rotate moves the file to a backup while it is still open, so w.file stays usable until the new
+// file is open. A file removed by someone else is not backed up.
+func (w *FileWriter) rotate() error {
+	backup := w.backupName(time.Now())
+	renameErr := os.Rename(w.path, backup)
+	if renameErr != nil && !errors.Is(renameErr, fs.ErrNotExist) {
+		return renameErr
+	}
+
+	f, size, opened, err := openLogFile(w.path)
+	if err != nil {
+		if renameErr == nil {
+			// Put the file back, so the next rotation finds it.
+			_ = os.Rename(backup, w.path)
+		}
+		return err
+	}
+
+	errs := []error{w.file.Close()}
+	w.file, w.size, w.opened = f, size, opened
+	if renameErr != nil {
+		return errors.Join(errs...)
+	}
+
+	if w.opts.Compress {
+		errs = append(errs, compressFile(backup))
+	}
+	errs = append(errs, w.removeOldBackups())
+	return errors.Join(errs...)
+}
+
+func (w *FileWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(w.path, ext), t.UTC().Format(backupTimeFormat), ext)
+}
+
+func (w *FileWriter) removeOldBackups() error {
+	if w.opts.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := w.backups()
+	if err != nil || len(backups) <= w.opts.MaxBackups {
+		return err
+	}
+
+	var errs []error
+	for _, backup := range backups[:len(backups)-w.opts.MaxBackups] {
+		errs = append(errs, os.Remove(backup))
+	}
+	return errors.Join(errs...)
+}
+
+// backups returns the rotated files, oldest first.
+func (w *FileWriter) backups() ([]string, error) {
+	ext := filepath.Ext(w.path)
+	prefix := filepath.Base(strings.TrimSuffix(w.path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(w.path), name))
+	}
+	slices.Sort(backups)
+	return backups, nil
+}
+
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
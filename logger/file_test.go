@@ -0,0 +1,171 @@
+package logger_test
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFileWriter(t *testing.T) {
+	t.Run("rotates by size and keeps max backups", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "logs", "app.log")
+		w, err := logger.NewFileWriter(path, logger.FileOptions{MaxSize: 10, MaxBackups: 2})
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+
+		for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+			_, err := w.Write([]byte(line))
+			require.NoError(t, err)
+		}
+
+		names := dirEntries(t, filepath.Join(dir, "logs"))
+		require.Len(t, names, 3)
+		assert.Equal(t, "app.log", names[2])
+		assert.Regexp(t, `^app-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{9}\.log$`, names[0])
+
+		current, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "fourth\n", string(current))
+		newest, err := os.ReadFile(filepath.Join(dir, "logs", names[1]))
+		require.NoError(t, err)
+		assert.Equal(t, "third\n", string(newest))
+	})
+
+	t.Run("rotates by age and compresses backups", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		w, err := logger.NewFileWriter(path, logger.FileOptions{MaxAge: 20 * time.Millisecond, Compress: true})
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("old\n"))
+		require.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+		_, err = w.Write([]byte("new\n"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		names := dirEntries(t, dir)
+		require.Len(t, names, 2)
+		assert.True(t, strings.HasSuffix(names[0], ".log.gz"), names[0])
+
+		f, err := os.Open(filepath.Join(dir, names[0]))
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		content, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, "old\n", string(content))
+
+		_, err = w.Write([]byte("closed"))
+		require.ErrorIs(t, err, os.ErrClosed)
+	})
+
+	t.Run("appends to an existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o600))
+
+		w, err := logger.NewFileWriter(path, logger.FileOptions{})
+		require.NoError(t, err)
+		l := logger.New(logger.WithWriter(w), logger.WithLevel(slog.LevelInfo))
+		l.Info("appended")
+		require.NoError(t, w.Close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "existing\n{"))
+		assert.Contains(t, string(content), `"message":"appended"`)
+	})
+	t.Run("keeps writing and retries when rotation fails", func(t *testing.T) {
+		root := t.TempDir()
+		dir, moved := filepath.Join(root, "logs"), filepath.Join(root, "moved")
+		w, err := logger.NewFileWriter(filepath.Join(dir, "app.log"), logger.FileOptions{MaxSize: 10})
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		_, err = w.Write([]byte("first\n"))
+		require.NoError(t, err)
+
+		// A file in place of the directory makes both the backup and the new file fail.
+		require.NoError(t, os.Rename(dir, moved))
+		require.NoError(t, os.WriteFile(dir, nil, 0o600))
+		n, err := w.Write([]byte("second\n"))
+		require.Error(t, err)
+		assert.Equal(t, 7, n)
+
+		require.NoError(t, os.Remove(dir))
+		require.NoError(t, os.Rename(moved, dir))
+		_, err = w.Write([]byte("third\n"))
+		require.NoError(t, err)
+
+		names := dirEntries(t, dir)
+		require.Len(t, names, 2)
+		backup, err := os.ReadFile(filepath.Join(dir, names[0]))
+		require.NoError(t, err)
+		assert.Equal(t, "first\nsecond\n", string(backup))
+		current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "third\n", string(current))
+	})
+
+	t.Run("reopens a file removed by someone else", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		w, err := logger.NewFileWriter(path, logger.FileOptions{MaxSize: 10})
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		_, err = w.Write([]byte("first\n"))
+		require.NoError(t, err)
+
+		require.NoError(t, os.Remove(path))
+		_, err = w.Write([]byte("second\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"app.log"}, dirEntries(t, dir))
+		current, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "second\n", string(current))
+	})
+}
+
+func TestFanoutHandler(t *testing.T) {
+	stdout, file := logtest.New(), logtest.New()
+	l := slog.New(logger.NewFanoutHandler(
+		logger.NewLevelHandler(slog.LevelInfo, stdout),
+		file,
+	)).With("service", "billing").WithGroup("job")
+
+	l.Debug("batch started", "id", 7)
+	l.Info("batch finished", "id", 7)
+
+	assert.Len(t, stdout.Records(), 1)
+	stdout.AssertLogged(t, slog.LevelInfo, "batch finished", "service", "billing", "job.id", 7)
+	assert.Len(t, file.Records(), 2)
+	file.AssertLogged(t, slog.LevelDebug, "batch started", "job.id", 7)
+
+	quiet := slog.New(logger.NewFanoutHandler(logger.NewLevelHandler(slog.LevelError, stdout)))
+	assert.False(t, quiet.Enabled(t.Context(), slog.LevelInfo))
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+const (
+	// GELFChunkSize is the default size of UDP datagrams, fitting common network MTUs.
+	GELFChunkSize = 1420
+
+	gelfMaxChunks = 128
+	// gelfChunkHeader is the size of the magic bytes, message id, sequence number and count.
+	gelfChunkHeader = 12
+)
+
+var ErrGELFMessageTooLarge = errors.New("gelf message exceeds 128 chunks")
+
+// NewGELFHandler returns a handler writing GELF 1.1 messages to w, one per Write, e.g. to a
+// GELFWriter. Fields are those of New, with dotted keys, added as additional fields prefixed
+// with an underscore, except error.stack_trace, which is the full_message. The host is the
+// host.name field, os.Hostname() without it. The writer and format options are ignored.
+//
+// Messages larger than the limit of a GELFWriter over UDP get their full_message, or their
+// short_message without one, truncated.
+func NewGELFHandler(w io.Writer, opts ...Option) slog.Handler {
+	o := newOptions(opts)
+	host := staticField(o, "host.name")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	var mu sync.Mutex
+	return newFieldsHandler(o, func(r slog.Record, attrs []slog.Attr) error {
+		var full string
+		fields := make([]slog.Attr, 0, len(attrs))
+		for _, a := range attrs {
+			if a.Key == "error.stack_trace" {
+				full = a.Value.String()
+				continue
+			}
+			fields = append(fields, a)
+		}
+
+		encode := func(short string, full string) []byte {
+			buf := &bytes.Buffer{}
+			buf.WriteByte('{')
+			appendJSONField(buf, "version", "1.1")
+			appendJSONField(buf, "host", host)
+			appendJSONField(buf, "short_message", short)
+			if full != "" {
+				appendJSONField(buf, "full_message", full)
+			}
+			appendJSONField(buf, "timestamp", float64(r.Time.UnixMicro())/1e6)
+			appendJSONField(buf, "level", severity(r.Level))
+			appendJSONField(buf, "_log.level", r.Level.String())
+			for _, a := range fields {
+				key := a.Key
+				if key == "id" {
+					// _id is reserved by GELF.
+					key = "id_"
+				}
+				appendJSONField(buf, "_"+key, fieldValue(a.Value))
+			}
+			buf.WriteByte('}')
+			return buf.Bytes()
+		}
+
+		var msg []byte
+		if full != "" {
+			msg = fitMessage(maxMessageSize(w), full, func(full string) []byte { return encode(r.Message, full) })
+		} else {
+			msg = fitMessage(maxMessageSize(w), r.Message, func(short string) []byte { return encode(short, "") })
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := w.Write(msg)
+		return err
+	})
+}
+
+// GELFWriter sends each Write as one GELF message. Over UDP messages larger than the chunk size
+// are split into GELF chunks, over TCP messages are terminated by a null byte and the connection
+// is dialed again when a write fails.
+type GELFWriter struct {
+	conn      *netConn
+	chunkSize int
+	mu        sync.Mutex
+}
+
+// Interface guard
+var _ io.WriteCloser = (*GELFWriter)(nil)
+
+// NewGELFWriter connects to a GELF input, network is "udp" or "tcp".
+func NewGELFWriter(network string, address string) (*GELFWriter, error) {
+	conn, err := dialNetConn("gelf", network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &GELFWriter{conn: conn, chunkSize: GELFChunkSize}, nil
+}
+
+// MaxMessageSize returns the size of the largest message that fits in 128 chunks over UDP, and 0
+// over TCP, which has no limit.
+func (g *GELFWriter) MaxMessageSize() int {
+	if !g.conn.udp {
+		return 0
+	}
+	return gelfMaxChunks * (g.chunkSize - gelfChunkHeader)
+}
+
+func (g *GELFWriter) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.conn.udp {
+		if err := g.conn.write(append(p[:len(p):len(p)], 0)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if len(p) <= g.chunkSize {
+		if err := g.conn.write(p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return len(p), g.writeChunks(p)
+}
+
+func (g *GELFWriter) writeChunks(p []byte) error {
+	payload := g.chunkSize - gelfChunkHeader
+	count := (len(p) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return ErrGELFMessageTooLarge
+	}
+
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	chunk := make([]byte, 0, g.chunkSize)
+	for i := range count {
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, p[i*payload:min((i+1)*payload, len(p))]...)
+		if err := g.conn.write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *GELFWriter) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.conn.close()
+}
@@ -0,0 +1,269 @@
+package logger_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	return conn
+}
+
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	buf := make([]byte, 65535)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return buf[:n]
+}
+
+// limitedBuffer is a writer with a message size limit, like GELFWriter and SyslogWriter over UDP.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (l *limitedBuffer) MaxMessageSize() int {
+	return l.limit
+}
+
+// assertReconnects writes messages until the server has closed the first connection and the
+// writer has dialed a new one, which then receives the message being written.
+func assertReconnects(t *testing.T, newWriter func(address string) (io.WriteCloser, error), contains func(frame []byte, message string) bool) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	w, err := newWriter(listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+	first, err := listener.Accept()
+	require.NoError(t, err)
+	require.NoError(t, first.Close())
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	l := slog.New(logger.NewGELFHandler(w))
+	if _, ok := w.(*logger.SyslogWriter); ok {
+		l = slog.New(logger.NewSyslogHandler(w, logger.FacilityUser))
+	}
+	deadline := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		message := "message-" + strconv.Itoa(i)
+		l.Info(message)
+		select {
+		case conn := <-accepted:
+			defer func() { _ = conn.Close() }()
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			var received []byte
+			buf := make([]byte, 4096)
+			for !contains(received, message) {
+				n, err := conn.Read(buf)
+				require.NoError(t, err, string(received))
+				received = append(received, buf[:n]...)
+			}
+			return
+		case <-deadline:
+			t.Fatal("writer did not reconnect")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestGELFHandler(t *testing.T) {
+	t.Run("writes gelf messages with the fields of New", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := slog.New(logger.NewGELFHandler(buf, logger.WithHostName("pod-1"), logger.WithServiceName("billing"), logger.WithLevel(slog.LevelInfo)))
+
+		l.Debug("hidden")
+		l.WithGroup("http").Warn("slow <response>", slog.Group("response", slog.Int("status_code", 200)), "id", 7, "err", errors.New("timeout"))
+
+		var message map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &message))
+		assert.Equal(t, "1.1", message["version"])
+		assert.Equal(t, "pod-1", message["host"])
+		assert.Equal(t, "slow <response>", message["short_message"])
+		assert.InDelta(t, float64(time.Now().Unix()), message["timestamp"], 5)
+		assert.InDelta(t, 4, message["level"], 0)
+		assert.Equal(t, "WARN", message["_log.level"])
+		assert.Equal(t, "billing", message["_service.name"])
+		assert.InDelta(t, 200, message["_http.response.status_code"], 0)
+		assert.InDelta(t, 7, message["_http.id"], 0)
+		assert.Equal(t, "timeout", message["_http.err"])
+		assert.Contains(t, buf.String(), "<response>")
+	})
+
+	t.Run("sends and chunks over udp", func(t *testing.T) {
+		server := listenUDP(t)
+		w, err := logger.NewGELFWriter("udp", server.LocalAddr().String())
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		l := slog.New(logger.NewGELFHandler(w))
+
+		l.Info("small")
+		var message map[string]any
+		require.NoError(t, json.Unmarshal(readDatagram(t, server), &message))
+		assert.Equal(t, "small", message["short_message"])
+
+		large := strings.Repeat("x", 3*logger.GELFChunkSize)
+		l.Info(large)
+
+		var payload []byte
+		var id []byte
+		for i := range 4 {
+			chunk := readDatagram(t, server)
+			require.LessOrEqual(t, len(chunk), logger.GELFChunkSize)
+			assert.Equal(t, []byte{0x1e, 0x0f}, chunk[:2])
+			if id == nil {
+				id = chunk[2:10]
+			}
+			assert.Equal(t, id, chunk[2:10])
+			assert.Equal(t, byte(i), chunk[10])
+			assert.Equal(t, byte(4), chunk[11])
+			payload = append(payload, chunk[12:]...)
+		}
+		require.NoError(t, json.Unmarshal(payload, &message))
+		assert.Equal(t, large, message["short_message"])
+
+		_, err = w.Write(make([]byte, 129*logger.GELFChunkSize))
+		require.ErrorIs(t, err, logger.ErrGELFMessageTooLarge)
+	})
+
+	t.Run("sends null terminated messages over tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+
+		w, err := logger.NewGELFWriter("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		l := slog.New(logger.NewGELFHandler(w))
+		l.Info("first")
+		l.Info("second")
+
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		for _, expected := range []string{"first", "second"} {
+			frame, err := reader.ReadBytes(0)
+			require.NoError(t, err)
+			var message map[string]any
+			require.NoError(t, json.Unmarshal(frame[:len(frame)-1], &message))
+			assert.Equal(t, expected, message["short_message"])
+		}
+	})
+
+	t.Run("sends the stack trace as full_message and truncates it to fit", func(t *testing.T) {
+		buf := &limitedBuffer{limit: 1000}
+		l := slog.New(logger.NewGELFHandler(buf, logger.WithHostName("pod-1")))
+
+		l.Error("failed", slog.Group("error", slog.String("message", "boom"), slog.String("stack_trace", strings.Repeat("main.go:1\n", 500))))
+
+		assert.LessOrEqual(t, buf.Len(), 1000)
+		var message map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &message))
+		assert.Equal(t, "failed", message["short_message"])
+		assert.Equal(t, "boom", message["_error.message"])
+		assert.NotContains(t, message, "_error.stack_trace")
+		full := message["full_message"].(string)
+		assert.True(t, strings.HasPrefix(full, "main.go:1\n"))
+		assert.True(t, strings.HasSuffix(full, "...[truncated]"), full)
+	})
+
+	t.Run("truncates the short_message without a full_message", func(t *testing.T) {
+		buf := &limitedBuffer{limit: 500}
+		slog.New(logger.NewGELFHandler(buf)).Info(strings.Repeat("é", 1000))
+
+		assert.LessOrEqual(t, buf.Len(), 500)
+		var message map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &message))
+		assert.True(t, strings.HasSuffix(message["short_message"].(string), "é...[truncated]"))
+	})
+
+	t.Run("limits udp messages to 128 chunks", func(t *testing.T) {
+		server := listenUDP(t)
+		udp, err := logger.NewGELFWriter("udp", server.LocalAddr().String())
+		require.NoError(t, err)
+		defer func() { _ = udp.Close() }()
+		assert.Equal(t, 128*(logger.GELFChunkSize-12), udp.MaxMessageSize())
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+		tcp, err := logger.NewGELFWriter("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = tcp.Close() }()
+		assert.Zero(t, tcp.MaxMessageSize())
+	})
+
+	t.Run("reconnects over tcp", func(t *testing.T) {
+		assertReconnects(t, func(address string) (io.WriteCloser, error) {
+			return logger.NewGELFWriter("tcp", address)
+		}, func(frame []byte, message string) bool {
+			return bytes.Contains(frame, []byte(`"short_message":"`+message+`"`))
+		})
+	})
+
+	t.Run("times out on stalled servers without sending partial messages again", func(t *testing.T) {
+		logger.SetNetTimeout(t, 100*time.Millisecond)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+
+		w, err := logger.NewGELFWriter("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		stalled, err := listener.Accept()
+		require.NoError(t, err)
+		defer func() { _ = stalled.Close() }()
+
+		// The server does not read, so writes block once the socket buffers are full
+		large := bytes.Repeat([]byte("x"), 1<<20)
+		start := time.Now()
+		for err == nil && time.Since(start) < 5*time.Second {
+			_, err = w.Write(large)
+		}
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		_, err = w.Write([]byte(`{"short_message":"after"}`))
+		require.NoError(t, err)
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		frame, err := bufio.NewReader(conn).ReadBytes(0)
+		require.NoError(t, err)
+		assert.Equal(t, `{"short_message":"after"}`+"\x00", string(frame))
+	})
+
+	t.Run("rejects unknown networks", func(t *testing.T) {
+		_, err := logger.NewGELFWriter("unix", "/tmp/gelf.sock")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+type KeyStyle int
+
+const (
+	// KeyStyleMixed keeps keys as logged, dotted keys stay dotted and groups become nested objects.
+	KeyStyleMixed KeyStyle = iota
+	// KeyStyleNested splits dotted keys into nested objects, {"http":{"request":{"method":"GET"}}}.
+	// Keys of the ECS log object, like log.level and log.logger, stay dotted.
+	KeyStyleNested
+	// KeyStyleDotted flattens groups into dotted keys, {"http.request.method":"GET"}.
+	KeyStyleDotted
+)
+
+type keyStyleHandler struct {
+	style   KeyStyle
+	handler slog.Handler
+	prefix  string
+	// attrs are the flattened attributes of WithAttrs, kept back in nested style so they can be
+	// merged with the record attributes sharing a parent object.
+	attrs []slog.Attr
+}
+
+// NewKeyStyleHandler wraps h, rewriting attribute keys, including those of WithGroup, to style.
+// The built-in message, level and time fields are left to h.
+func NewKeyStyleHandler(h slog.Handler, style KeyStyle) slog.Handler {
+	if style == KeyStyleMixed {
+		return h
+	}
+	return &keyStyleHandler{style: style, handler: h}
+}
+
+func (k *keyStyleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return k.handler.Enabled(ctx, level)
+}
+
+func (k *keyStyleHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(k.attrs)+r.NumAttrs())
+	attrs = append(attrs, k.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = flattenAttr(attrs, k.prefix, a)
+		return true
+	})
+
+	styled := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	if k.style == KeyStyleNested {
+		styled.AddAttrs(nestAttrs(attrs)...)
+	} else {
+		styled.AddAttrs(attrs...)
+	}
+	return k.handler.Handle(ctx, styled)
+}
+
+func (k *keyStyleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var flattened []slog.Attr
+	for _, a := range attrs {
+		flattened = flattenAttr(flattened, k.prefix, a)
+	}
+
+	if k.style == KeyStyleNested {
+		return &keyStyleHandler{style: k.style, handler: k.handler, prefix: k.prefix, attrs: append(k.attrs[:len(k.attrs):len(k.attrs)], flattened...)}
+	}
+	return &keyStyleHandler{style: k.style, handler: k.handler.WithAttrs(flattened), prefix: k.prefix}
+}
+
+func (k *keyStyleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return k
+	}
+	return &keyStyleHandler{style: k.style, handler: k.handler, prefix: k.prefix + name + ".", attrs: k.attrs}
+}
+
+func flattenAttr(dst []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return dst
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(dst, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		dst = flattenAttr(dst, prefix, ga)
+	}
+	return dst
+}
+
+type keyNode struct {
+	key      string
+	value    slog.Value
+	leaf     bool
+	children []*keyNode
+}
+
+// child returns the child named key, turning a leaf of that name into a parent.
+func (n *keyNode) child(key string) *keyNode {
+	for _, c := range n.children {
+		if c.key == key {
+			c.leaf = false
+			return c
+		}
+	}
+	c := &keyNode{key: key}
+	n.children = append(n.children, c)
+	return c
+}
+
+// set sets the leaf named key, replacing a leaf or parent of that name.
+func (n *keyNode) set(key string, value slog.Value) {
+	c := n.child(key)
+	c.leaf, c.value, c.children = true, value, nil
+}
+
+func (n *keyNode) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, len(n.children))
+	for i, c := range n.children {
+		if c.leaf {
+			attrs[i] = slog.Attr{Key: c.key, Value: c.value}
+		} else {
+			attrs[i] = slog.Attr{Key: c.key, Value: slog.GroupValue(c.attrs()...)}
+		}
+	}
+	return attrs
+}
+
+// nestAttrs turns dotted keys into nested groups, merging attributes sharing a parent in the
+// order they first appear. Each key is written once: when a key is both a value and a parent,
+// e.g. "a" and "a.b", the attribute logged last wins. Keys of the ECS log object stay dotted,
+// like the built-in log.level, so there is no "log" object next to it.
+func nestAttrs(attrs []slog.Attr) []slog.Attr {
+	root := &keyNode{}
+	for _, a := range attrs {
+		if strings.HasPrefix(a.Key, "log.") {
+			root.set(a.Key, a.Value)
+			continue
+		}
+		node := root
+		parts := strings.Split(a.Key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			node = node.child(part)
+		}
+		node.set(parts[len(parts)-1], a.Value)
+	}
+	return root.attrs()
+}
@@ -0,0 +1,101 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyStyle(t *testing.T) {
+	ctx := logger.ContextWithTrace(context.Background(), logger.TraceContext{TraceID: "trace-1"})
+
+	t.Run("nested", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(logger.WithWriter(buf), logger.WithKeyStyle(logger.KeyStyleNested), logger.WithServiceName("billing"))
+
+		l.With("http.request.id", "req-1").WithGroup("http").InfoContext(ctx, "handled",
+			"request.method", "GET",
+			slog.Group("response", slog.Int("status_code", 200)),
+		)
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "handled", record["message"])
+		assert.Equal(t, "INFO", record["log.level"])
+		assert.Equal(t, map[string]any{"name": "billing"}, record["service"])
+		assert.Equal(t, map[string]any{"id": "trace-1"}, record["trace"])
+		assert.Equal(t, map[string]any{
+			"request":  map[string]any{"id": "req-1", "method": "GET"},
+			"response": map[string]any{"status_code": float64(200)},
+		}, record["http"])
+		assert.NotContains(t, record, "http.request.id")
+	})
+
+	t.Run("nested keeps the log object dotted", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.WithName(logger.New(logger.WithWriter(buf), logger.WithKeyStyle(logger.KeyStyleNested), logger.WithAddSource()), "billing")
+
+		l.Info("handled", "log.custom", "x")
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "INFO", record["log.level"])
+		assert.Equal(t, "billing", record["log.logger"])
+		assert.Equal(t, "x", record["log.custom"])
+		assert.Contains(t, record["log.origin.function"], "TestKeyStyle")
+		assert.NotContains(t, record, "log")
+	})
+
+	t.Run("nested writes a key that is both a value and a parent once", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(logger.WithWriter(buf), logger.WithKeyStyle(logger.KeyStyleNested))
+
+		l.Info("parent last", "a", 1, "a.b", 2, "c", 3)
+		assert.Equal(t, 1, strings.Count(buf.String(), `"a":`))
+		record := decodeLine(t, buf)
+		assert.Equal(t, map[string]any{"b": float64(2)}, record["a"])
+		assert.InDelta(t, 3, record["c"], 0)
+
+		l.With("a.b", 2).Info("value last", "a", 1, "a", 4)
+		assert.Equal(t, 1, strings.Count(buf.String(), `"a":`))
+		assert.InDelta(t, 4, decodeLine(t, buf)["a"], 0)
+	})
+
+	t.Run("dotted", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(logger.WithWriter(buf), logger.WithKeyStyle(logger.KeyStyleDotted), logger.WithAddSource())
+
+		l.WithGroup("job").With(slog.Group("batch", slog.Int("size", 10))).ErrorContext(ctx, "failed",
+			logger.GetSlogAttrFromError(errors.New("boom")),
+			"attempt", 2,
+		)
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "trace-1", record["trace.id"])
+		assert.InDelta(t, 10, record["job.batch.size"], 0)
+		assert.InDelta(t, 2, record["job.attempt"], 0)
+		assert.Equal(t, "boom", record["job.error.message"])
+		assert.Contains(t, record, "job.error.stack_trace")
+		assert.Contains(t, record["log.origin.function"], "TestKeyStyle")
+		for key, value := range record {
+			_, nested := value.(map[string]any)
+			require.False(t, nested, key)
+		}
+	})
+
+	t.Run("mixed keeps keys as logged", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := slog.New(logger.NewKeyStyleHandler(slog.NewJSONHandler(buf, nil), logger.KeyStyleMixed))
+
+		l.Info("hello", "a.b", 1, slog.Group("c", slog.Int("d", 2)))
+
+		record := decodeLine(t, buf)
+		assert.InDelta(t, 1, record["a.b"], 0)
+		assert.Equal(t, map[string]any{"d": float64(2)}, record["c"])
+	})
+}
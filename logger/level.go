@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cego/go-lib/v2/headers"
+)
+
+// LevelEnv is read by LevelFromEnv and by New when no level is given.
+const LevelEnv = "LOG_LEVEL"
+
+// ParseLevel parses debug, info, warn, warning and error case-insensitively, as well as
+// slog's offset syntax like "info+2".
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "warning") {
+		s = "warn"
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// LevelFromEnv returns a LevelVar set from the LOG_LEVEL environment variable, or fallback if
+// it is unset or invalid.
+func LevelFromEnv(fallback slog.Level) *slog.LevelVar {
+	lv := &slog.LevelVar{}
+	lv.Set(fallback)
+	if value := os.Getenv(LevelEnv); value != "" {
+		if level, err := ParseLevel(value); err == nil {
+			lv.Set(level)
+		}
+	}
+	return lv
+}
+
+type levelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+type levelResponse struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// maxLevelRequestBody is the size limit of LevelEndpoint PUT bodies.
+const maxLevelRequestBody = 1 << 10
+
+// LevelEndpoint is an http.Handler reading (GET) and changing (PUT) a LevelVar at runtime.
+// A PUT body looks like {"level":"debug","ttl":"10m"}, with an optional ttl after which the
+// level reverts to what it was before the first temporary change. Bodies larger than 1 KiB are
+// rejected with 413.
+type LevelEndpoint struct {
+	levelVar *slog.LevelVar
+	logger   *slog.Logger
+
+	mu         sync.Mutex
+	timer      *time.Timer
+	generation int
+	revertTo   slog.Level
+	revertAt   time.Time
+}
+
+func NewLevelEndpoint(lv *slog.LevelVar, l *slog.Logger) *LevelEndpoint {
+	return &LevelEndpoint{levelVar: lv, logger: l}
+}
+
+func (e *LevelEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		e.respond(w, http.StatusOK)
+	case http.MethodPut:
+		var body levelRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLevelRequestBody)).Decode(&body); err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		level, err := ParseLevel(body.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ttl time.Duration
+		if body.TTL != "" {
+			if ttl, err = time.ParseDuration(body.TTL); err != nil || ttl <= 0 {
+				http.Error(w, fmt.Sprintf("invalid ttl %q", body.TTL), http.StatusBadRequest)
+				return
+			}
+		}
+		e.Set(level, ttl)
+		e.respond(w, http.StatusOK)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// Set changes the level, reverting it after ttl unless ttl is 0.
+func (e *LevelEndpoint) Set(level slog.Level, ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	previous := e.levelVar.Level()
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	} else {
+		e.revertTo = previous
+	}
+
+	e.levelVar.Set(level)
+	e.generation++
+	if ttl > 0 {
+		generation := e.generation
+		e.revertAt = time.Now().Add(ttl)
+		e.timer = time.AfterFunc(ttl, func() { e.revert(generation) })
+		e.logger.Info("log level changed", "log.level.previous", previous.String(), "log.level.current", level.String(), "log.level.ttl", ttl.String())
+		return
+	}
+	e.logger.Info("log level changed", "log.level.previous", previous.String(), "log.level.current", level.String())
+}
+
+func (e *LevelEndpoint) revert(generation int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if generation != e.generation {
+		return
+	}
+	e.timer = nil
+	previous := e.levelVar.Level()
+	e.levelVar.Set(e.revertTo)
+	e.logger.Info("log level reverted", "log.level.previous", previous.String(), "log.level.current", e.revertTo.String())
+}
+
+func (e *LevelEndpoint) respond(w http.ResponseWriter, status int) {
+	e.mu.Lock()
+	response := levelResponse{Level: e.levelVar.Level().String()}
+	if e.timer != nil {
+		revertAt := e.revertAt
+		response.RevertAt = &revertAt
+	}
+	e.mu.Unlock()
+
+	w.Header().Set(headers.ContentType, "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
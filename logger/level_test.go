@@ -0,0 +1,87 @@
+package logger_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for input, expected := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warning": slog.LevelWarn, "error": slog.LevelError, "info+2": slog.LevelInfo + 2} {
+		level, err := logger.ParseLevel(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, level)
+	}
+
+	_, err := logger.ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestLevelFromEnv(t *testing.T) {
+	t.Setenv(logger.LevelEnv, "warn")
+	assert.Equal(t, slog.LevelWarn, logger.LevelFromEnv(slog.LevelDebug).Level())
+
+	buf := &bytes.Buffer{}
+	l := logger.New(logger.WithWriter(buf))
+	l.Info("hidden")
+	assert.Empty(t, buf.String())
+
+	t.Setenv(logger.LevelEnv, "loud")
+	assert.Equal(t, slog.LevelDebug, logger.LevelFromEnv(slog.LevelDebug).Level())
+}
+
+func TestLevelEndpoint(t *testing.T) {
+	request := func(h http.Handler, method string, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/loglevel", strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("reads and changes the level", func(t *testing.T) {
+		lv := &slog.LevelVar{}
+		lv.Set(slog.LevelInfo)
+		l, buf := newBufferLogger()
+		e := logger.NewLevelEndpoint(lv, l)
+
+		assert.JSONEq(t, `{"level":"INFO"}`, request(e, http.MethodGet, "").Body.String())
+		assert.JSONEq(t, `{"level":"DEBUG"}`, request(e, http.MethodPut, `{"level":"debug"}`).Body.String())
+		assert.Equal(t, slog.LevelDebug, lv.Level())
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "log level changed", record["msg"])
+		assert.Equal(t, "INFO", record["log.level.previous"])
+		assert.Equal(t, "DEBUG", record["log.level.current"])
+	})
+
+	t.Run("reverts after ttl", func(t *testing.T) {
+		lv := &slog.LevelVar{}
+		lv.Set(slog.LevelWarn)
+		l, _ := newBufferLogger()
+		e := logger.NewLevelEndpoint(lv, l)
+
+		rec := request(e, http.MethodPut, `{"level":"debug","ttl":"50ms"}`)
+		assert.Contains(t, rec.Body.String(), "revert_at")
+		request(e, http.MethodPut, `{"level":"info","ttl":"50ms"}`)
+		assert.Equal(t, slog.LevelInfo, lv.Level())
+
+		assert.Eventually(t, func() bool { return lv.Level() == slog.LevelWarn }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		e := logger.NewLevelEndpoint(&slog.LevelVar{}, slog.Default())
+
+		assert.Equal(t, http.StatusMethodNotAllowed, request(e, http.MethodPost, `{"level":"debug"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, request(e, http.MethodPut, `{"level":"loud"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, request(e, http.MethodPut, `{"level":"debug","ttl":"soon"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, request(e, http.MethodPut, `nope`).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, request(e, http.MethodPut, `{"level":"debug","ttl":"`+strings.Repeat("1", 2<<10)+`s"}`).Code)
+	})
+}
@@ -1,15 +1,18 @@
 package logger
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
-	"os"
-	"runtime/debug"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/stackerr"
 )
 
 type Logger interface {
@@ -18,49 +21,201 @@ type Logger interface {
 	Error(message string, args ...any)
 }
 
-func New() *slog.Logger {
-	return newSlogger(slog.LevelDebug)
+// ContextLogger extends Logger with Warn and context-aware methods, *slog.Logger implements it.
+type ContextLogger interface {
+	Logger
+	Warn(message string, args ...any)
+	DebugContext(ctx context.Context, message string, args ...any)
+	InfoContext(ctx context.Context, message string, args ...any)
+	WarnContext(ctx context.Context, message string, args ...any)
+	ErrorContext(ctx context.Context, message string, args ...any)
+}
+
+// Interface guard
+var _ ContextLogger = (*slog.Logger)(nil)
+
+// AsContextLogger returns l if it implements ContextLogger. Otherwise the context is dropped and
+// warnings, including WarnContext, are logged with the Warn method of l, or with Info if it has none.
+func AsContextLogger(l Logger) ContextLogger {
+	if cl, ok := l.(ContextLogger); ok {
+		return cl
+	}
+	return contextLogger{l}
+}
+
+type contextLogger struct {
+	Logger
+}
+
+func (l contextLogger) Warn(message string, args ...any) {
+	if wl, ok := l.Logger.(interface{ Warn(string, ...any) }); ok {
+		wl.Warn(message, args...)
+		return
+	}
+	l.Info(message, args...)
+}
+
+func (l contextLogger) DebugContext(_ context.Context, message string, args ...any) {
+	l.Debug(message, args...)
+}
+
+func (l contextLogger) InfoContext(_ context.Context, message string, args ...any) {
+	l.Info(message, args...)
+}
+
+func (l contextLogger) WarnContext(_ context.Context, message string, args ...any) {
+	l.Warn(message, args...)
+}
+
+func (l contextLogger) ErrorContext(_ context.Context, message string, args ...any) {
+	l.Error(message, args...)
+}
+
+func New(opts ...Option) *slog.Logger {
+	return slog.New(NewHandler(opts...))
 }
 
 func NewWithLevel(level slog.Level) *slog.Logger {
-	return newSlogger(level)
+	return New(WithLevel(level))
+}
+
+// NewHandler returns the handler behind New, for composing with other handlers.
+func NewHandler(opts ...Option) slog.Handler {
+	o := newOptions(opts)
+
+	replaceAttr := replaceECSAttr
+	if o.keyStyle != KeyStyleMixed {
+		// log.origin is dotted in nested style as well, like the built-in log.level.
+		replaceAttr = dottedReplaceAttr
+	}
+
+	var h slog.Handler
+	switch o.format {
+	case FormatText:
+		h = slog.NewTextHandler(o.writer, &slog.HandlerOptions{Level: o.level, AddSource: o.addSource})
+	case FormatConsole:
+		h = newConsoleHandler(o.writer, o.level, o.addSource)
+	default:
+		h = newECSHandler(o.writer, o.level, o.addSource, replaceAttr)
+	}
+
+	h = NewKeyStyleHandler(h, o.keyStyle)
+	if len(o.fields) > 0 {
+		h = h.WithAttrs(o.fields)
+	}
+	return NewTraceHandler(h)
+}
+
+func replaceECSAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.MessageKey {
+		a.Key = "message"
+	}
+	if a.Key == slog.LevelKey {
+		a.Key = "log.level"
+	}
+	if a.Key == slog.TimeKey {
+		a.Key = "@timestamp"
+		a.Value = slog.StringValue(a.Value.Time().UTC().Format(time.RFC3339Nano))
+	}
+	if a.Key == slog.SourceKey && len(groups) == 0 {
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			a = slog.Group("log.origin",
+				slog.Group("file", slog.String("name", src.File), slog.Int("line", src.Line)),
+				slog.String("function", src.Function),
+			)
+		}
+	}
+	return a
 }
 
-func newSlogger(level slog.Level) *slog.Logger {
-	opts := &slog.HandlerOptions{
-		Level: level,
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			if a.Key == slog.MessageKey {
-				a.Key = "message"
-			}
-			if a.Key == slog.LevelKey {
-				a.Key = "log.level"
-			}
-			if a.Key == slog.TimeKey {
-				a.Key = "@timestamp"
-				a.Value = slog.StringValue(a.Value.Time().UTC().Format(time.RFC3339Nano))
-			}
-			return a
-		},
+// dottedReplaceAttr flattens the log.origin group of replaceECSAttr into dotted keys.
+func dottedReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	a = replaceECSAttr(groups, a)
+	if a.Value.Kind() == slog.KindGroup {
+		return slog.Attr{Value: slog.GroupValue(flattenAttr(nil, "", a)...)}
 	}
-	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	return a
 }
 
+// GetSlogAttrFromError returns ECS error fields. The stack trace is the one captured by stackerr
+// closest to the origin of err, or the stack of the caller if there is none.
 func GetSlogAttrFromError(err error) slog.Attr {
-	return slog.Group("error",
+	frames := stackerr.StackTrace(err)
+	if frames == nil {
+		frames = stackerr.Callers(1)
+	}
+
+	attrs := []any{
 		slog.String("message", err.Error()),
-		slog.String("stack_trace", string(debug.Stack())),
-	)
+		slog.String("type", fmt.Sprintf("%T", stackerr.Origin(err))),
+		slog.String("stack_trace", stackerr.Format(trimLoggerFrames(frames))),
+	}
+
+	causes := stackerr.Causes(err)
+	if len(causes) > 0 {
+		chain := make([]map[string]string, len(causes))
+		for i, cause := range causes {
+			chain[i] = map[string]string{"message": cause.Error(), "type": fmt.Sprintf("%T", cause)}
+		}
+		attrs = append(attrs, slog.Any("cause", chain))
+	}
+
+	return slog.Group("error", attrs...)
+}
+
+func trimLoggerFrames(frames []stackerr.Frame) []stackerr.Frame {
+	trimmed := make([]stackerr.Frame, 0, len(frames))
+	for _, f := range frames {
+		if !strings.HasPrefix(f.Function, "github.com/cego/go-lib/v2/logger.") {
+			trimmed = append(trimmed, f)
+		}
+	}
+	return trimmed
+}
+
+type RequestAttrOption func(o *requestAttrOptions)
+
+type requestAttrOptions struct {
+	ecs       bool
+	policy    *RedactionPolicy
+	bodyLimit int
+}
+
+// WithECSFields makes GetSlogAttrFromRequest emit nested ECS client, http, url, user_agent and
+// user fields, with headers as attributes instead of a raw JSON string.
+func WithECSFields() RequestAttrOption {
+	return func(o *requestAttrOptions) {
+		o.ecs = true
+	}
 }
 
-func GetSlogAttrFromRequest(req *http.Request) slog.Attr {
+// WithRequestRedaction sets the policy used to mask headers and query, instead of the one set with SetRedactionPolicy.
+func WithRequestRedaction(p *RedactionPolicy) RequestAttrOption {
+	return func(o *requestAttrOptions) {
+		o.policy = p
+	}
+}
+
+func GetSlogAttrFromRequest(req *http.Request, opts ...RequestAttrOption) slog.Attr {
+	o := &requestAttrOptions{policy: currentRedactionPolicy()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var body []byte
+	if o.bodyLimit > 0 && req.Body != nil && req.Body != http.NoBody {
+		body = peekBody(&req.Body, o.bodyLimit)
+	}
+
+	if o.ecs {
+		return ecsRequestAttr(req, o.policy, body, o.bodyLimit)
+	}
+
 	var attrs []slog.Attr
 
 	reqHeaders := req.Header
 
-	remoteAddr := req.RemoteAddr
-	clientIp, _, _ := net.SplitHostPort(remoteAddr)
-	attrs = append(attrs, slog.String("client.ip", clientIp))
+	attrs = append(attrs, slog.String("client.ip", clientIP(req)))
 
 	if reqHeaders.Get(headers.XForwardedFor) != "" {
 		attrs = append(attrs, slog.String("client.address", reqHeaders.Get(headers.XForwardedFor)))
@@ -69,19 +224,104 @@ func GetSlogAttrFromRequest(req *http.Request) slog.Attr {
 		attrs = append(attrs, slog.String("user_agent.original", reqHeaders.Get(headers.UserAgent)))
 	}
 
-	h := reqHeaders.Clone()
-	if h.Get(headers.Cookie) != "" {
-		h.Set(headers.Cookie, "<masked>")
-	}
-	if h.Get(headers.Authorization) != "" {
-		h.Set(headers.Authorization, "<masked>")
-	}
+	h := o.policy.RedactHeaders(reqHeaders)
 	if len(h) > 0 {
 		headersJsonMarshalled, _ := json.Marshal(h)
 		attrs = append(attrs, slog.String("http.request.headers.raw", string(headersJsonMarshalled)))
 	}
+	if body != nil {
+		attrs = append(attrs, slog.String("http.request.body.content", redactBody(body, reqHeaders, o.policy, o.bodyLimit)))
+	}
 
 	attr := slog.Attr{}
 	attr.Value = slog.GroupValue(attrs...)
 	return attr
 }
+
+func ecsRequestAttr(req *http.Request, policy *RedactionPolicy, body []byte, bodyLimit int) slog.Attr {
+	client := []any{slog.String("ip", clientIP(req))}
+	if xff := req.Header.Get(headers.XForwardedFor); xff != "" {
+		client = append(client, slog.String("address", xff))
+	}
+
+	request := []any{slog.String("method", req.Method)}
+	if requestID := requestIDOf(req); requestID != "" {
+		request = append(request, slog.String("id", requestID))
+	}
+	var bodyAttrs []any
+	if req.ContentLength >= 0 {
+		bodyAttrs = append(bodyAttrs, slog.Int64("bytes", req.ContentLength))
+	}
+	if body != nil {
+		bodyAttrs = append(bodyAttrs, slog.String("content", redactBody(body, req.Header, policy, bodyLimit)))
+	}
+	if len(bodyAttrs) > 0 {
+		request = append(request, slog.Group("body", bodyAttrs...))
+	}
+	if len(req.Header) > 0 {
+		redacted := policy.RedactHeaders(req.Header)
+		names := make([]string, 0, len(redacted))
+		for name := range redacted {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		var headerAttrs []any
+		for _, name := range names {
+			headerAttrs = append(headerAttrs, slog.String(strings.ToLower(name), strings.Join(redacted[name], ", ")))
+		}
+		request = append(request, slog.Group("headers", headerAttrs...))
+	}
+
+	host, port := req.Host, ""
+	if h, p, err := net.SplitHostPort(req.Host); err == nil {
+		host, port = h, p
+	}
+	urlAttrs := []any{
+		slog.String("scheme", requestScheme(req)),
+		slog.String("domain", host),
+	}
+	if port != "" {
+		urlAttrs = append(urlAttrs, slog.String("port", port))
+	}
+	urlAttrs = append(urlAttrs, slog.String("path", req.URL.Path))
+	if req.URL.RawQuery != "" {
+		urlAttrs = append(urlAttrs, slog.String("query", policy.RedactQuery(req.URL.RawQuery)))
+	}
+
+	attrs := []slog.Attr{
+		slog.Group("client", client...),
+		slog.Group("http", slog.String("version", fmt.Sprintf("%d.%d", req.ProtoMajor, req.ProtoMinor)), slog.Group("request", request...)),
+		slog.Group("url", urlAttrs...),
+	}
+	if ua := req.Header.Get(headers.UserAgent); ua != "" {
+		attrs = append(attrs, slog.Group("user_agent", slog.String("original", ua)))
+	}
+	if user := req.Header.Get(headers.RemoteUser); user != "" {
+		attrs = append(attrs, slog.Group("user", slog.String("name", user)))
+	}
+
+	return slog.Attr{Value: slog.GroupValue(attrs...)}
+}
+
+func requestIDOf(req *http.Request) string {
+	if requestID := RequestID(req.Context()); requestID != "" {
+		return requestID
+	}
+	if requestID := req.Header.Get(headers.XRequestId); validRequestID(requestID) {
+		return requestID
+	}
+	return ""
+}
+
+func requestScheme(req *http.Request) string {
+	if proto := req.Header.Get(headers.XForwardedProto); proto != "" {
+		return proto
+	}
+	if req.URL.Scheme != "" {
+		return req.URL.Scheme
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -1,14 +1,20 @@
 package logger_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/cego/go-lib/v2/headers"
 	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/stackerr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -48,6 +54,40 @@ func TestLogger(t *testing.T) {
 		l.AssertCalled(t, "Debug", "Epic request data is attached", mock.MatchedBy(MatchSlogGroup("", validators)))
 	})
 
+	t.Run("it can get nested ecs request attr", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "https://shop.example.com:8443/orders?id=1&token=abc", strings.NewReader("{}"))
+		req.Header.Set(headers.XForwardedFor, "85.4.4.5")
+		req.Header.Set(headers.UserAgent, "curl/8.7")
+		req.Header.Set(headers.Authorization, "secret")
+		req.Header.Set(headers.RemoteUser, "alice")
+		req.Header.Set(headers.XRequestId, "req-1")
+		buf := &bytes.Buffer{}
+		l := slog.New(slog.NewJSONHandler(buf, nil))
+
+		l.Info("request", logger.GetSlogAttrFromRequest(req, logger.WithECSFields()))
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, map[string]any{"ip": "192.0.2.1", "address": "85.4.4.5"}, record["client"])
+		assert.Equal(t, map[string]any{
+			"version": "1.1",
+			"request": map[string]any{
+				"method": "POST",
+				"id":     "req-1",
+				"body":   map[string]any{"bytes": float64(2)},
+				"headers": map[string]any{
+					"authorization":   "<masked>",
+					"remote-user":     "alice",
+					"user-agent":      "curl/8.7",
+					"x-forwarded-for": "85.4.4.5",
+					"x-request-id":    "req-1",
+				},
+			},
+		}, record["http"])
+		assert.Equal(t, map[string]any{"scheme": "https", "domain": "shop.example.com", "port": "8443", "path": "/orders", "query": "id=1&token=<masked>"}, record["url"])
+		assert.Equal(t, map[string]any{"original": "curl/8.7"}, record["user_agent"])
+		assert.Equal(t, map[string]any{"name": "alice"}, record["user"])
+	})
+
 	t.Run("it can get err attr", func(t *testing.T) {
 		err := errors.New("test error")
 		l := logger.NewMock()
@@ -61,8 +101,72 @@ func TestLogger(t *testing.T) {
 		}
 		l.AssertCalled(t, "Error", "Something has failed here", mock.MatchedBy(MatchSlogGroup("error", validators)))
 	})
+
+	t.Run("it reports the stack at the error origin and the cause chain", func(t *testing.T) {
+		pathErr := &fs.PathError{Op: "open", Path: "/etc/app.yml", Err: fs.ErrNotExist}
+		err := fmt.Errorf("load config: %w", loadConfig(pathErr))
+
+		record := logAttr(t, logger.GetSlogAttrFromError(err))
+
+		errAttr := record["error"].(map[string]any)
+		assert.Equal(t, "load config: open /etc/app.yml: file does not exist", errAttr["message"])
+		assert.Equal(t, "*fmt.wrapError", errAttr["type"])
+		stack := errAttr["stack_trace"].(string)
+		assert.True(t, strings.HasPrefix(stack, "github.com/cego/go-lib/v2/logger_test.loadConfig\n"), stack)
+		assert.NotContains(t, stack, "runtime.")
+		assert.NotContains(t, stack, "github.com/cego/go-lib/v2/logger.")
+		assert.Equal(t, []any{
+			map[string]any{"message": "open /etc/app.yml: file does not exist", "type": "*fs.PathError"},
+			map[string]any{"message": "file does not exist", "type": "*errors.errorString"},
+		}, errAttr["cause"])
+	})
+}
+
+func TestAsContextLogger(t *testing.T) {
+	t.Run("it keeps context loggers", func(t *testing.T) {
+		l := logger.NewMock()
+		assert.Same(t, l, logger.AsContextLogger(l))
+	})
+
+	t.Run("it adapts plain loggers", func(t *testing.T) {
+		l := &plainLogger{}
+		cl := logger.AsContextLogger(l)
+
+		cl.Warn("warn")
+		cl.DebugContext(context.Background(), "debug")
+		cl.InfoContext(context.Background(), "info")
+		cl.WarnContext(context.Background(), "warn context")
+		cl.ErrorContext(context.Background(), "error")
+
+		assert.Equal(t, []string{"INFO warn", "DEBUG debug", "INFO info", "INFO warn context", "ERROR error"}, l.lines)
+	})
+
+	t.Run("it logs warnings with the Warn method of plain loggers", func(t *testing.T) {
+		l := &warnLogger{}
+		cl := logger.AsContextLogger(l)
+
+		cl.Warn("warn")
+		cl.WarnContext(context.Background(), "warn context")
+
+		assert.Equal(t, []string{"WARN warn", "WARN warn context"}, l.lines)
+	})
 }
 
+type plainLogger struct {
+	lines []string
+}
+
+// warnLogger has Warn, but none of the context methods of ContextLogger.
+type warnLogger struct {
+	plainLogger
+}
+
+func (w *warnLogger) Warn(message string, _ ...any) { w.lines = append(w.lines, "WARN "+message) }
+
+func (p *plainLogger) Debug(message string, _ ...any) { p.lines = append(p.lines, "DEBUG "+message) }
+func (p *plainLogger) Info(message string, _ ...any)  { p.lines = append(p.lines, "INFO "+message) }
+func (p *plainLogger) Error(message string, _ ...any) { p.lines = append(p.lines, "ERROR "+message) }
+
 func MatchSlogGroup(expectedKey string, validators map[string]func(string) bool) func(any) bool {
 	return func(arg any) bool {
 		attr := extractSlogAttr(arg)
@@ -92,3 +196,7 @@ func validateAllAttrs(attrs []slog.Attr, validators map[string]func(string) bool
 	}
 	return len(satisfied) == len(validators)
 }
+
+func loadConfig(err error) error {
+	return stackerr.Wrap(err)
+}
@@ -0,0 +1,188 @@
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Record is a captured log record. Attrs are flattened to dotted keys including groups,
+// e.g. slog.Group("http", slog.Int("status", 200)) is stored as "http.status".
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%s %q %v", r.Level, r.Message, r.Attrs)
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// Handler is a slog.Handler capturing records at every level for assertions in tests.
+// Handlers derived through WithAttrs and WithGroup share the records of their parent.
+type Handler struct {
+	recorder *recorder
+	attrs    map[string]any
+	prefix   string
+}
+
+// Interface guard
+var _ slog.Handler = (*Handler)(nil)
+
+func New() *Handler {
+	return &Handler{recorder: &recorder{}, attrs: map[string]any{}}
+}
+
+// Logger returns a logger writing to h, usable as logger.Logger and logger.ContextLogger.
+func (h *Handler) Logger() *slog.Logger {
+	return slog.New(h)
+}
+
+func (h *Handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flatten(attrs, h.prefix, a)
+		return true
+	})
+
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+	h.recorder.records = append(h.recorder.records, Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: attrs})
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flattened := make(map[string]any, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		flattened[k] = v
+	}
+	for _, a := range attrs {
+		flatten(flattened, h.prefix, a)
+	}
+	return &Handler{recorder: h.recorder, attrs: flattened, prefix: h.prefix}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{recorder: h.recorder, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Records returns a copy of the captured records in the order they were logged.
+func (h *Handler) Records() []Record {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+	return append([]Record(nil), h.recorder.records...)
+}
+
+func (h *Handler) Reset() {
+	h.recorder.mu.Lock()
+	defer h.recorder.mu.Unlock()
+	h.recorder.records = nil
+}
+
+// Find returns the records at level whose message contains msgSubstring and which have all attrs.
+// Attrs are given like slog arguments, as key value pairs or slog.Attr, and matched by flattened key.
+func (h *Handler) Find(level slog.Level, msgSubstring string, attrs ...any) []Record {
+	expected := expectedAttrs(attrs)
+
+	var found []Record
+	for _, r := range h.Records() {
+		if r.Level == level && strings.Contains(r.Message, msgSubstring) && hasAttrs(r, expected) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// AssertLogged asserts that at least one record matches, see Find.
+func (h *Handler) AssertLogged(t testing.TB, level slog.Level, msgSubstring string, attrs ...any) bool {
+	t.Helper()
+	if len(h.Find(level, msgSubstring, attrs...)) > 0 {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("no %s record containing %q with %v", level, msgSubstring, expectedAttrs(attrs)), h.dump())
+}
+
+// AssertNotLogged asserts that no record matches, see Find.
+func (h *Handler) AssertNotLogged(t testing.TB, level slog.Level, msgSubstring string, attrs ...any) bool {
+	t.Helper()
+	found := h.Find(level, msgSubstring, attrs...)
+	if len(found) == 0 {
+		return true
+	}
+	return assert.Fail(t, fmt.Sprintf("unexpected %s record containing %q", level, msgSubstring), fmt.Sprint(found))
+}
+
+func (h *Handler) dump() string {
+	records := h.Records()
+	if len(records) == 0 {
+		return "no records were logged"
+	}
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = r.String()
+	}
+	return "logged records:\n" + strings.Join(lines, "\n")
+}
+
+func hasAttrs(r Record, expected map[string]any) bool {
+	for k, v := range expected {
+		actual, ok := r.Attrs[k]
+		if !ok || !assert.ObjectsAreEqual(v, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// expectedAttrs flattens attrs through a slog.Record so values are normalized the same way as
+// captured ones, e.g. int becomes int64.
+func expectedAttrs(attrs []any) map[string]any {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(attrs...)
+
+	expected := map[string]any{}
+	r.Attrs(func(a slog.Attr) bool {
+		flatten(expected, "", a)
+		return true
+	})
+	return expected
+}
+
+func flatten(dst map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		dst[prefix+a.Key] = a.Value.Any()
+		return
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		flatten(dst, prefix, ga)
+	}
+}
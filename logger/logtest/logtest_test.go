@@ -0,0 +1,81 @@
+package logtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/cego/go-lib/v2/renderer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("records flattened attributes", func(t *testing.T) {
+		h := logtest.New()
+		l := h.Logger().With("service", "billing").WithGroup("http")
+
+		l.Info("request handled", slog.Group("response", slog.Int("status", 200)), "path", "/orders", slog.Group("empty"))
+
+		records := h.Records()
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelInfo, records[0].Level)
+		assert.Equal(t, "request handled", records[0].Message)
+		assert.Equal(t, map[string]any{
+			"service":              "billing",
+			"http.response.status": int64(200),
+			"http.path":            "/orders",
+		}, records[0].Attrs)
+	})
+
+	t.Run("finds records by level, message and attributes", func(t *testing.T) {
+		h := logtest.New()
+		l := h.Logger()
+
+		l.Debug("cache miss", "key", "a")
+		l.ErrorContext(context.Background(), "payment failed", "order.id", 42, slog.Group("error", slog.String("message", "declined")))
+
+		assert.True(t, h.AssertLogged(t, slog.LevelError, "payment", "order.id", 42, "error.message", "declined"))
+		assert.True(t, h.AssertLogged(t, slog.LevelError, "failed", slog.Group("error", slog.String("message", "declined"))))
+		assert.True(t, h.AssertNotLogged(t, slog.LevelInfo, "cache miss"))
+		assert.Len(t, h.Find(slog.LevelDebug, ""), 1)
+		assert.Empty(t, h.Find(slog.LevelError, "payment", "order.id", 43))
+
+		ft := &fakeT{TB: t}
+		assert.False(t, h.AssertLogged(ft, slog.LevelError, "payment", "order.id", "42"))
+		assert.Contains(t, ft.failure, `ERROR "payment failed"`)
+
+		h.Reset()
+		assert.Empty(t, h.Records())
+	})
+
+	t.Run("is usable as logger.Logger", func(t *testing.T) {
+		h := logtest.New()
+		var l logger.Logger = h.Logger()
+		r := renderer.New(l)
+
+		r.Text(failingWriter{}, http.StatusOK, "hello")
+
+		h.AssertLogged(t, slog.LevelError, "write failed")
+	})
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Header() http.Header       { return http.Header{} }
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+func (failingWriter) WriteHeader(int)           {}
+
+type fakeT struct {
+	testing.TB
+	failure string
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.failure = fmt.Sprintf(format, args...)
+}
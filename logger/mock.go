@@ -1,11 +1,13 @@
 package logger
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 )
 
 // Interface guard
-var _ Logger = (*Mock)(nil)
+var _ ContextLogger = (*Mock)(nil)
 
 type Mock struct {
 	mock.Mock
@@ -15,7 +17,12 @@ func NewMock() *Mock {
 	m := &Mock{}
 	m.On("Debug", mock.Anything, mock.Anything).Return(nil)
 	m.On("Info", mock.Anything, mock.Anything).Return(nil)
+	m.On("Warn", mock.Anything, mock.Anything).Return(nil)
 	m.On("Error", mock.Anything, mock.Anything).Return(nil)
+	m.On("DebugContext", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.On("InfoContext", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.On("WarnContext", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.On("ErrorContext", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	return m
 }
 
@@ -30,3 +37,23 @@ func (l *Mock) Info(message string, args ...any) {
 func (l *Mock) Error(message string, args ...any) {
 	l.Called(message, args)
 }
+
+func (l *Mock) Warn(message string, args ...any) {
+	l.Called(message, args)
+}
+
+func (l *Mock) DebugContext(ctx context.Context, message string, args ...any) {
+	l.Called(ctx, message, args)
+}
+
+func (l *Mock) InfoContext(ctx context.Context, message string, args ...any) {
+	l.Called(ctx, message, args)
+}
+
+func (l *Mock) WarnContext(ctx context.Context, message string, args ...any) {
+	l.Called(ctx, message, args)
+}
+
+func (l *Mock) ErrorContext(ctx context.Context, message string, args ...any) {
+	l.Called(ctx, message, args)
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LevelsEnv configures levels of named loggers, e.g. LOG_LEVELS=forwardauth=debug,periodic=warn.
+const LevelsEnv = "LOG_LEVELS"
+
+var (
+	namedLevels     sync.Map
+	namedLevelsOnce sync.Once
+)
+
+// ParseLevels parses a comma separated list of name=level pairs.
+func ParseLevels(s string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid named log level %q", pair)
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+		levels[strings.TrimSpace(name)] = level
+	}
+	return levels, nil
+}
+
+// SetNamedLevel sets the level of loggers named name and their children, overriding LOG_LEVELS.
+func SetNamedLevel(name string, level slog.Level) {
+	loadNamedLevels()
+	lv, _ := namedLevels.LoadOrStore(name, &slog.LevelVar{})
+	lv.(*slog.LevelVar).Set(level)
+}
+
+func loadNamedLevels() {
+	namedLevelsOnce.Do(func() {
+		levels, err := ParseLevels(os.Getenv(LevelsEnv))
+		if err != nil {
+			return
+		}
+		for name, level := range levels {
+			lv := &slog.LevelVar{}
+			lv.Set(level)
+			namedLevels.Store(name, lv)
+		}
+	})
+}
+
+// ClearNamedLevel removes the level of name, set by SetNamedLevel or LOG_LEVELS, so the level of
+// its closest configured parent or of the wrapped handler applies again.
+func ClearNamedLevel(name string) {
+	loadNamedLevels()
+	namedLevels.Delete(name)
+}
+
+// namedLevel finds the level of name or its closest configured parent, "a.b" falls back to "a".
+func namedLevel(name string) (slog.Level, bool) {
+	loadNamedLevels()
+	for {
+		if lv, ok := namedLevels.Load(name); ok {
+			return lv.(*slog.LevelVar).Level(), true
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// Named returns a child of slog.Default() named name, see WithName.
+func Named(name string) *slog.Logger {
+	return WithName(slog.Default(), name)
+}
+
+// WithName returns a child of l adding the log.logger field. Its level can be configured per name
+// through LOG_LEVELS or SetNamedLevel, otherwise the level of l applies. Naming a named logger
+// again joins the names with a dot.
+func WithName(l *slog.Logger, name string) *slog.Logger {
+	h := l.Handler()
+	if nh, ok := h.(*namedHandler); ok {
+		name = nh.name + "." + name
+		h = nh.base
+	}
+	return slog.New(&namedHandler{
+		name:    name,
+		base:    h,
+		handler: h.WithAttrs([]slog.Attr{slog.String("log.logger", name)}),
+	})
+}
+
+type namedHandler struct {
+	name    string
+	base    slog.Handler
+	handler slog.Handler
+}
+
+// levelOverrideKey carries the level configured for a named logger down the handler chain.
+type levelOverrideKey struct{}
+
+// Enabled replaces the level of the wrapped handler when a level is configured for the name.
+func (n *namedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if configured, ok := namedLevel(n.name); ok {
+		return level >= configured
+	}
+	return n.handler.Enabled(ctx, level)
+}
+
+// Handle passes the configured level down the chain, so handlers checking the level of the
+// handlers they wrap again for each record, like the fanout handler, apply it as well.
+func (n *namedHandler) Handle(ctx context.Context, r slog.Record) error {
+	if configured, ok := namedLevel(n.name); ok {
+		ctx = context.WithValue(ctx, levelOverrideKey{}, configured)
+	}
+	return n.handler.Handle(ctx, r)
+}
+
+// handlerEnabled reports whether h is enabled for level, or the level of a named logger passed
+// down the chain if there is one.
+func handlerEnabled(ctx context.Context, h slog.Handler, level slog.Level) bool {
+	if configured, ok := ctx.Value(levelOverrideKey{}).(slog.Level); ok {
+		return level >= configured
+	}
+	return h.Enabled(ctx, level)
+}
+
+func (n *namedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &namedHandler{name: n.name, base: n.base.WithAttrs(attrs), handler: n.handler.WithAttrs(attrs)}
+}
+
+func (n *namedHandler) WithGroup(name string) slog.Handler {
+	return &namedHandler{name: n.name, base: n.base.WithGroup(name), handler: n.handler.WithGroup(name)}
+}
@@ -0,0 +1,86 @@
+package logger_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := logger.ParseLevels("forwardauth=debug, periodic=warn,,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]slog.Level{"forwardauth": slog.LevelDebug, "periodic": slog.LevelWarn}, levels)
+
+	_, err = logger.ParseLevels("forwardauth")
+	require.Error(t, err)
+	_, err = logger.ParseLevels("forwardauth=loud")
+	require.Error(t, err)
+}
+
+func TestNamed(t *testing.T) {
+	t.Run("adds log.logger and joins nested names", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.WithName(logger.New(logger.WithWriter(buf)), "named-test-a")
+
+		l.Info("hello")
+		assert.Equal(t, "named-test-a", decodeLine(t, buf)["log.logger"])
+
+		logger.WithName(l.With("k", "v"), "cache").Info("hello")
+		record := decodeLine(t, buf)
+		assert.Equal(t, "named-test-a.cache", record["log.logger"])
+		assert.Equal(t, "v", record["k"])
+	})
+
+	t.Run("levels are configured per name and inherited", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		base := logger.New(logger.WithWriter(buf), logger.WithLevel(slog.LevelInfo))
+		noisy := logger.WithName(base, "named-test-noisy")
+		child := logger.WithName(noisy, "child")
+		quiet := logger.WithName(base, "named-test-quiet")
+
+		noisy.Debug("hidden by base level")
+		assert.Empty(t, buf.String())
+
+		logger.SetNamedLevel("named-test-noisy", slog.LevelDebug)
+		logger.SetNamedLevel("named-test-quiet", slog.LevelError)
+		t.Cleanup(func() {
+			logger.ClearNamedLevel("named-test-noisy")
+			logger.ClearNamedLevel("named-test-quiet")
+		})
+
+		child.Debug("visible")
+		assert.Equal(t, "named-test-noisy.child", decodeLine(t, buf)["log.logger"])
+
+		quiet.Warn("hidden by named level")
+		base.Warn("visible")
+		assert.NotContains(t, decodeLine(t, buf), "log.logger")
+
+		logger.ClearNamedLevel("named-test-noisy")
+		child.Debug("hidden by base level again")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("levels apply to every output of a fanout", func(t *testing.T) {
+		stdout, file := logtest.New(), logtest.New()
+		base := slog.New(logger.NewFanoutHandler(
+			logger.NewLevelHandler(slog.LevelInfo, stdout),
+			logger.NewLevelHandler(slog.LevelWarn, file),
+		))
+		l := logger.WithName(base, "named-test-fanout")
+
+		logger.SetNamedLevel("named-test-fanout", slog.LevelDebug)
+		t.Cleanup(func() { logger.ClearNamedLevel("named-test-fanout") })
+
+		l.Debug("visible everywhere")
+		stdout.AssertLogged(t, slog.LevelDebug, "visible everywhere", "log.logger", "named-test-fanout")
+		file.AssertLogged(t, slog.LevelDebug, "visible everywhere")
+
+		base.Debug("hidden by the output levels")
+		stdout.AssertNotLogged(t, slog.LevelDebug, "hidden")
+	})
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// netTimeout bounds dialing and every write, as writes block the goroutine that logs.
+var netTimeout = 5 * time.Second
+
+var errNetConnBackoff = errors.New("not connected, waiting to dial again")
+
+// netConn is the connection of GELFWriter and SyslogWriter. Over TCP a failed write closes the
+// connection and a message none of which was written is sent again on a new one, so a restarted
+// log server does not stop logging. If dialing fails, writes fail without dialing until netTimeout
+// has passed.
+type netConn struct {
+	network string
+	address string
+	conn    net.Conn
+	udp     bool
+	retryAt time.Time
+}
+
+func dialNetConn(kind string, network string, address string) (*netConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("unsupported %s network %q", kind, network)
+	}
+	conn, err := net.DialTimeout(network, address, netTimeout)
+	if err != nil {
+		return nil, err
+	}
+	_, udp := conn.(*net.UDPConn)
+	return &netConn{network: network, address: address, conn: conn, udp: udp}, nil
+}
+
+func (c *netConn) write(p []byte) error {
+	if c.conn != nil {
+		n, err := c.writeDeadline(p)
+		if err == nil || c.udp {
+			return err
+		}
+		_ = c.conn.Close()
+		c.conn = nil
+		if n > 0 {
+			// Sending the message again would give the server a corrupt or duplicate frame
+			return err
+		}
+	}
+
+	if time.Now().Before(c.retryAt) {
+		return errNetConnBackoff
+	}
+	conn, err := net.DialTimeout(c.network, c.address, netTimeout)
+	if err != nil {
+		c.retryAt = time.Now().Add(netTimeout)
+		return err
+	}
+	c.conn = conn
+	if _, err := c.writeDeadline(p); err != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (c *netConn) writeDeadline(p []byte) (int, error) {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(netTimeout)); err != nil {
+		return 0, err
+	}
+	return c.conn.Write(p)
+}
+
+func (c *netConn) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+type Format int
+
+const (
+	// FormatJSON is the ECS shaped JSON produced by default.
+	FormatJSON Format = iota
+	// FormatText is the logfmt style output of slog.TextHandler.
+	FormatText
+	// FormatConsole is colored, human-readable output for local development.
+	FormatConsole
+)
+
+const ECSVersion = "8.11.0"
+
+type Option func(o *options)
+
+type options struct {
+	writer    io.Writer
+	level     slog.Leveler
+	format    Format
+	addSource bool
+	keyStyle  KeyStyle
+	fields    []slog.Attr
+}
+
+func WithWriter(w io.Writer) Option {
+	return func(o *options) {
+		o.writer = w
+	}
+}
+
+// WithLevel sets the minimum level, pass a *slog.LevelVar to change it at runtime. Without it
+// the level is read from LOG_LEVEL, defaulting to debug.
+func WithLevel(level slog.Leveler) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithAddSource adds the log.origin fields of the logging call site.
+func WithAddSource() Option {
+	return func(o *options) {
+		o.addSource = true
+	}
+}
+
+// WithKeyStyle makes attribute keys consistently nested or dotted, see KeyStyle.
+func WithKeyStyle(style KeyStyle) Option {
+	return func(o *options) {
+		o.keyStyle = style
+	}
+}
+
+func WithServiceName(name string) Option {
+	return withField("service.name", name)
+}
+
+func WithServiceVersion(version string) Option {
+	return withField("service.version", version)
+}
+
+func WithServiceEnvironment(environment string) Option {
+	return withField("service.environment", environment)
+}
+
+// WithHostName adds host.name, os.Hostname() is used when name is empty.
+func WithHostName(name string) Option {
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	return withField("host.name", name)
+}
+
+// WithECSVersion adds ecs.version, ECSVersion is used when version is empty.
+func WithECSVersion(version string) Option {
+	if version == "" {
+		version = ECSVersion
+	}
+	return withField("ecs.version", version)
+}
+
+func withField(key string, value string) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, slog.String(key, value))
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		writer: os.Stdout,
+		format: FormatJSON,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.level == nil {
+		o.level = LevelFromEnv(slog.LevelDebug)
+	}
+
+	return o
+}
@@ -0,0 +1,72 @@
+package logger_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	t.Run("writes ecs json with static fields", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(
+			logger.WithWriter(buf),
+			logger.WithLevel(slog.LevelInfo),
+			logger.WithServiceName("billing"),
+			logger.WithServiceVersion("1.2.3"),
+			logger.WithServiceEnvironment("production"),
+			logger.WithHostName("pod-1"),
+			logger.WithECSVersion(""),
+		)
+
+		l.Debug("hidden")
+		l.Info("hello", "a", 1)
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "hello", record["message"])
+		assert.Equal(t, "INFO", record["log.level"])
+		assert.Contains(t, record, "@timestamp")
+		assert.Equal(t, "billing", record["service.name"])
+		assert.Equal(t, "1.2.3", record["service.version"])
+		assert.Equal(t, "production", record["service.environment"])
+		assert.Equal(t, "pod-1", record["host.name"])
+		assert.Equal(t, logger.ECSVersion, record["ecs.version"])
+	})
+
+	t.Run("adds log origin", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(logger.WithWriter(buf), logger.WithAddSource())
+
+		l.Info("hello")
+
+		origin, ok := decodeLine(t, buf)["log.origin"].(map[string]any)
+		assert.True(t, ok)
+		assert.Contains(t, origin["function"], "TestOptions")
+		assert.Contains(t, origin["file"].(map[string]any)["name"], "options_test.go")
+	})
+
+	t.Run("writes text", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(logger.WithWriter(buf), logger.WithFormat(logger.FormatText))
+
+		l.Info("hello", "a", 1)
+
+		assert.Contains(t, buf.String(), `level=INFO msg=hello a=1`)
+	})
+
+	t.Run("writes colored console output", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := logger.New(logger.WithWriter(buf), logger.WithFormat(logger.FormatConsole), logger.WithServiceName("billing"))
+
+		l.With("user", "alice smith").WithGroup("req").Warn("slow request", "ms", 1200)
+
+		out := buf.String()
+		assert.Contains(t, out, "\033[33mWRN\033[0m slow request")
+		assert.Contains(t, out, "service.name=\033[0mbilling")
+		assert.Contains(t, out, "user=\033[0m\"alice smith\"")
+		assert.Contains(t, out, "req.ms=\033[0m1200\n")
+	})
+}
@@ -0,0 +1,269 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+const Masked = "<masked>"
+
+var (
+	JWTPattern        = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	cardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// RedactionPolicy describes what request logging helpers and the redact handler mask.
+// Name matching is case-insensitive.
+type RedactionPolicy struct {
+	// Headers are masked entirely.
+	Headers []string
+	// QueryParams have their values masked.
+	QueryParams []string
+	// JSONFields are masked in JSON bodies. A plain name matches the field at any depth,
+	// a dotted path like "user.password" only matches from the root.
+	JSONFields []string
+	// Keys are attribute keys masked by the redact handler. A plain name also matches the last
+	// segment of dotted keys and grouped attributes.
+	Keys []string
+	// Patterns are masked wherever they occur in string values.
+	Patterns []*regexp.Regexp
+	// CardNumbers masks Luhn-valid payment card numbers in string values.
+	CardNumbers bool
+}
+
+var redactionPolicy atomic.Pointer[RedactionPolicy]
+
+func init() {
+	redactionPolicy.Store(DefaultRedactionPolicy())
+}
+
+// DefaultRedactionPolicy returns a new policy with the built-in defaults, ready to be extended.
+func DefaultRedactionPolicy() *RedactionPolicy {
+	return &RedactionPolicy{
+		Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"},
+		QueryParams: []string{"token", "access_token", "refresh_token", "id_token", "code", "password", "secret", "api_key", "apikey"},
+		JSONFields:  []string{"password", "passwd", "secret", "client_secret", "token", "access_token", "refresh_token", "api_key", "card_number", "cvv"},
+		Keys:        []string{"password", "secret", "token", "api_key", "authorization", "cookie"},
+		Patterns:    []*regexp.Regexp{JWTPattern},
+		CardNumbers: true,
+	}
+}
+
+// SetRedactionPolicy replaces the policy used by GetSlogAttrFromRequest and access logs
+// that were not given one explicitly.
+func SetRedactionPolicy(p *RedactionPolicy) {
+	redactionPolicy.Store(p)
+}
+
+func currentRedactionPolicy() *RedactionPolicy {
+	return redactionPolicy.Load()
+}
+
+// RedactHeaders returns a copy of h with masked headers and patterns replaced.
+func (p *RedactionPolicy) RedactHeaders(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		if containsFold(p.Headers, name) {
+			redacted[name] = []string{Masked}
+			continue
+		}
+		redacted[name] = make([]string, len(values))
+		for i, v := range values {
+			redacted[name][i] = p.RedactString(v)
+		}
+	}
+	return redacted
+}
+
+// RedactQuery masks the values of QueryParams, keeping the order of the raw query.
+func (p *RedactionPolicy) RedactQuery(rawQuery string) string {
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, _, hasValue := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && containsFold(p.QueryParams, name) {
+			pairs[i] = key + "=" + Masked
+			continue
+		}
+		pairs[i] = p.RedactString(pair)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// RedactJSON masks JSONFields and patterns in a JSON document. Bodies that are not valid JSON,
+// e.g. cut off documents, are masked entirely, as their fields cannot be found.
+func (p *RedactionPolicy) RedactJSON(body []byte) []byte {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return []byte(Masked)
+	}
+	redacted, err := json.Marshal(p.redactJSONValue(doc, ""))
+	if err != nil {
+		return []byte(Masked)
+	}
+	return redacted
+}
+
+func (p *RedactionPolicy) redactJSONValue(v any, path string) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, child := range value {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if p.matchesJSONField(key, childPath) {
+				value[key] = Masked
+				continue
+			}
+			value[key] = p.redactJSONValue(child, childPath)
+		}
+		return value
+	case []any:
+		for i, child := range value {
+			value[i] = p.redactJSONValue(child, path)
+		}
+		return value
+	case string:
+		return p.RedactString(value)
+	}
+	return v
+}
+
+func (p *RedactionPolicy) matchesJSONField(key string, path string) bool {
+	for _, field := range p.JSONFields {
+		if strings.Contains(field, ".") {
+			if strings.EqualFold(field, path) {
+				return true
+			}
+		} else if strings.EqualFold(field, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString replaces Patterns and, if enabled, card numbers in s.
+func (p *RedactionPolicy) RedactString(s string) string {
+	for _, pattern := range p.Patterns {
+		s = pattern.ReplaceAllString(s, Masked)
+	}
+	if p.CardNumbers {
+		s = cardNumberPattern.ReplaceAllStringFunc(s, func(match string) string {
+			if luhnValid(match) {
+				return Masked
+			}
+			return match
+		})
+	}
+	return s
+}
+
+func (p *RedactionPolicy) matchesKey(key string) bool {
+	if containsFold(p.Keys, key) {
+		return true
+	}
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return containsFold(p.Keys, key[i+1:])
+	}
+	return false
+}
+
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := rune(number[i])
+		if !unicode.IsDigit(c) {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(item string) bool { return strings.EqualFold(item, s) })
+}
+
+type redactHandler struct {
+	handler slog.Handler
+	policy  *RedactionPolicy
+	groups  string
+}
+
+// NewRedactHandler wraps h, masking attributes whose key matches the policy Keys and replacing
+// patterns in string values.
+func NewRedactHandler(h slog.Handler, p *RedactionPolicy) slog.Handler {
+	return &redactHandler{handler: h, policy: p}
+}
+
+func (r *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return r.handler.Enabled(ctx, level)
+}
+
+func (r *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, r.policy.RedactString(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(r.redactAttr(a, r.groups))
+		return true
+	})
+	return r.handler.Handle(ctx, redacted)
+}
+
+func (r *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = r.redactAttr(a, r.groups)
+	}
+	return &redactHandler{handler: r.handler.WithAttrs(redacted), policy: r.policy, groups: r.groups}
+}
+
+func (r *redactHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	return &redactHandler{handler: r.handler.WithGroup(name), policy: r.policy, groups: r.groups + name + "."}
+}
+
+func (r *redactHandler) redactAttr(a slog.Attr, groups string) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if r.policy.matchesKey(a.Key) || (groups != "" && r.policy.matchesKey(groups+a.Key)) {
+		return slog.String(a.Key, Masked)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		prefix := groups
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = r.redactAttr(ga, prefix)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		return slog.String(a.Key, r.policy.RedactString(a.Value.String()))
+	}
+	return a
+}
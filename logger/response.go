@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cego/go-lib/v2/headers"
+)
+
+// ResponseWriter wraps an http.ResponseWriter, recording status, bytes written and duration,
+// and optionally capturing the start of the body.
+type ResponseWriter struct {
+	http.ResponseWriter
+	start        time.Time
+	status       int
+	bytes        int64
+	captureLimit int
+	body         bytes.Buffer
+}
+
+// Interface guard
+var (
+	_ http.Flusher  = (*ResponseWriter)(nil)
+	_ http.Hijacker = (*ResponseWriter)(nil)
+)
+
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, start: time.Now()}
+}
+
+// CaptureBody keeps the first limit bytes written, see Body.
+func (rw *ResponseWriter) CaptureBody(limit int) {
+	rw.captureLimit = limit
+}
+
+func (rw *ResponseWriter) WriteHeader(status int) {
+	if rw.status == 0 && status >= http.StatusOK {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	if remaining := captureSize(rw.captureLimit) - rw.body.Len(); rw.captureLimit > 0 && remaining > 0 {
+		rw.body.Write(b[:min(n, remaining)])
+	}
+	return n, err
+}
+
+func (rw *ResponseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+func (rw *ResponseWriter) BytesWritten() int64 {
+	return rw.bytes
+}
+
+// Duration is the time since the ResponseWriter was created.
+func (rw *ResponseWriter) Duration() time.Duration {
+	return time.Since(rw.start)
+}
+
+// Body returns the captured start of the body.
+func (rw *ResponseWriter) Body() []byte {
+	return rw.body.Bytes()[:min(rw.body.Len(), rw.captureLimit)]
+}
+
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush sends buffered data to the client, if the underlying writer supports it.
+func (rw *ResponseWriter) Flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack lets the handler take over the connection, e.g. for websockets.
+func (rw *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil && rw.status == 0 {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// GetSlogAttrFromResponseWriter returns the status, bytes, headers, duration and captured body of a
+// server response.
+func GetSlogAttrFromResponseWriter(rw *ResponseWriter) slog.Attr {
+	policy := currentRedactionPolicy()
+	attrs := []slog.Attr{
+		slog.Int("http.response.status_code", rw.Status()),
+		slog.Int64("http.response.body.bytes", rw.BytesWritten()),
+		slog.Int64("event.duration", rw.Duration().Nanoseconds()),
+	}
+	attrs = appendHeadersAttr(attrs, "http.response.headers.raw", rw.Header(), policy)
+	if rw.captureLimit > 0 {
+		attrs = append(attrs, slog.String("http.response.body.content", redactBody(rw.body.Bytes(), rw.Header(), policy, rw.captureLimit)))
+	}
+	return slog.Attr{Value: slog.GroupValue(attrs...)}
+}
+
+type ResponseAttrOption func(o *responseAttrOptions)
+
+type responseAttrOptions struct {
+	bodyLimit int
+}
+
+// WithResponseBody captures the first limit bytes of the body. The body stays readable by the caller.
+func WithResponseBody(limit int) ResponseAttrOption {
+	return func(o *responseAttrOptions) {
+		o.bodyLimit = limit
+	}
+}
+
+// GetSlogAttrFromResponse returns the status, size and headers of an outbound response.
+func GetSlogAttrFromResponse(resp *http.Response, opts ...ResponseAttrOption) slog.Attr {
+	o := &responseAttrOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return slog.Attr{Value: slog.GroupValue(appendResponseAttrs(nil, resp, currentRedactionPolicy(), o.bodyLimit)...)}
+}
+
+func appendResponseAttrs(attrs []slog.Attr, resp *http.Response, policy *RedactionPolicy, bodyLimit int) []slog.Attr {
+	attrs = append(attrs, slog.Int("http.response.status_code", resp.StatusCode))
+	if resp.ContentLength >= 0 {
+		attrs = append(attrs, slog.Int64("http.response.body.bytes", resp.ContentLength))
+	}
+	attrs = appendHeadersAttr(attrs, "http.response.headers.raw", resp.Header, policy)
+	if bodyLimit > 0 && resp.Body != nil {
+		body := peekBody(&resp.Body, bodyLimit)
+		attrs = append(attrs, slog.String("http.response.body.content", redactBody(body, resp.Header, policy, bodyLimit)))
+	}
+	return attrs
+}
+
+// WithRequestBody makes GetSlogAttrFromRequest capture the first limit bytes of the body as
+// http.request.body.content. The body stays readable by the handler.
+func WithRequestBody(limit int) RequestAttrOption {
+	return func(o *requestAttrOptions) {
+		o.bodyLimit = limit
+	}
+}
+
+func appendHeadersAttr(attrs []slog.Attr, key string, h http.Header, policy *RedactionPolicy) []slog.Attr {
+	if len(h) == 0 {
+		return attrs
+	}
+	marshalled, _ := json.Marshal(policy.RedactHeaders(h))
+	return append(attrs, slog.String(key, string(marshalled)))
+}
+
+// captureSize is how much of a body is kept for a capture limit, one byte more so that longer
+// bodies do not parse as complete JSON documents.
+func captureSize(limit int) int {
+	return limit + 1
+}
+
+// peekBody reads the part of body needed to capture limit bytes and replaces it with a reader
+// yielding the full body again.
+func peekBody(body *io.ReadCloser, limit int) []byte {
+	captured, _ := io.ReadAll(io.LimitReader(*body, int64(captureSize(limit))))
+	*body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), *body), *body}
+	return captured
+}
+
+// redactBody redacts the captured body and then cuts it at limit. JSON bodies longer than limit are
+// cut off documents and masked entirely.
+func redactBody(body []byte, h http.Header, policy *RedactionPolicy, limit int) string {
+	var redacted string
+	if strings.Contains(h.Get(headers.ContentType), "json") {
+		redacted = string(policy.RedactJSON(body))
+	} else {
+		redacted = policy.RedactString(string(body))
+	}
+	return redacted[:min(len(redacted), limit)]
+}
@@ -0,0 +1,176 @@
+package logger_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logAttr(t *testing.T, attr slog.Attr) map[string]any {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	slog.New(slog.NewJSONHandler(buf, nil)).Info("attr", attr)
+	return decodeLine(t, buf)
+}
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := logger.NewResponseWriter(rec)
+	rw.CaptureBody(16)
+
+	rw.Header().Set(headers.ContentType, "application/json")
+	rw.Header().Set("Set-Cookie", "session=abc")
+	rw.WriteHeader(http.StatusAccepted)
+	_, _ = rw.Write([]byte(`{"password":"p"}`))
+	_, _ = rw.Write([]byte(`ignored by capture`))
+
+	assert.Equal(t, http.StatusAccepted, rw.Status())
+	assert.Equal(t, int64(34), rw.BytesWritten())
+	assert.Equal(t, `{"password":"p"}`, string(rw.Body()))
+	assert.Positive(t, rw.Duration())
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	record := logAttr(t, logger.GetSlogAttrFromResponseWriter(rw))
+	assert.InDelta(t, 202, record["http.response.status_code"], 0)
+	assert.InDelta(t, 34, record["http.response.body.bytes"], 0)
+	assert.Contains(t, record, "event.duration")
+	assert.JSONEq(t, `{"Content-Type":["application/json"],"Set-Cookie":["<masked>"]}`, record["http.response.headers.raw"].(string))
+	// The full body is not valid JSON
+	assert.Equal(t, logger.Masked, record["http.response.body.content"])
+
+	rw = logger.NewResponseWriter(httptest.NewRecorder())
+	rw.CaptureBody(64)
+	rw.Header().Set(headers.ContentType, "application/json")
+	_, _ = rw.Write([]byte(`{"user":"alice",`))
+	_, _ = rw.Write([]byte(`"password":"p"}`))
+
+	record = logAttr(t, logger.GetSlogAttrFromResponseWriter(rw))
+	assert.JSONEq(t, `{"user":"alice","password":"<masked>"}`, record["http.response.body.content"].(string))
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, nil, nil
+}
+
+func TestResponseWriterPassThrough(t *testing.T) {
+	t.Run("flushes the underlying writer", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := logger.NewResponseWriter(rec)
+
+		rw.Flush()
+
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, http.StatusOK, rw.Status())
+	})
+
+	t.Run("hijacks the underlying connection", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+		rw := logger.NewResponseWriter(&hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server})
+
+		conn, _, err := rw.Hijack()
+
+		require.NoError(t, err)
+		assert.Same(t, server, conn)
+		assert.Equal(t, http.StatusSwitchingProtocols, rw.Status())
+	})
+
+	t.Run("reports writers that cannot be hijacked", func(t *testing.T) {
+		_, _, err := logger.NewResponseWriter(httptest.NewRecorder()).Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+	})
+}
+
+func TestGetSlogAttrFromResponse(t *testing.T) {
+	resp := &http.Response{
+		StatusCode:    http.StatusBadGateway,
+		ContentLength: 22,
+		Header:        http.Header{headers.ContentType: {"text/plain"}},
+		Body:          io.NopCloser(strings.NewReader("upstream failed badly!")),
+	}
+
+	record := logAttr(t, logger.GetSlogAttrFromResponse(resp, logger.WithResponseBody(8)))
+
+	assert.InDelta(t, 502, record["http.response.status_code"], 0)
+	assert.InDelta(t, 22, record["http.response.body.bytes"], 0)
+	assert.Equal(t, "upstream", record["http.response.body.content"])
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "upstream failed badly!", string(body))
+}
+
+func TestRequestBodyCapture(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"user":"alice","password":"p"}`))
+	req.Header.Set(headers.ContentType, "application/json")
+
+	record := logAttr(t, logger.GetSlogAttrFromRequest(req, logger.WithRequestBody(1024)))
+	assert.JSONEq(t, `{"user":"alice","password":"<masked>"}`, record["http.request.body.content"].(string))
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":"alice","password":"p"}`, string(body))
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"user":"alice","password":"p"}`))
+	record = logAttr(t, logger.GetSlogAttrFromRequest(req, logger.WithECSFields(), logger.WithRequestBody(4)))
+	assert.Equal(t, map[string]any{"bytes": float64(31), "content": "{\"us"}, record["http"].(map[string]any)["request"].(map[string]any)["body"])
+}
+
+func TestBodyCaptureRedactsBeforeCutting(t *testing.T) {
+	body := `{"user":"alice","password":"hunter2","note":"` + strings.Repeat("x", 64) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(headers.ContentType, "application/json")
+
+	record := logAttr(t, logger.GetSlogAttrFromRequest(req, logger.WithRequestBody(48)))
+	assert.Equal(t, logger.Masked, record["http.request.body.content"], "cut off JSON bodies are masked")
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("password hunter2 "+strings.Repeat("x", 64)))
+	record = logAttr(t, logger.GetSlogAttrFromRequest(req, logger.WithRequestBody(16)))
+	assert.Equal(t, "password hunter2", record["http.request.body.content"])
+}
+
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestBodyCaptureReadsOnlyTheLimit(t *testing.T) {
+	body := &countingReader{Reader: strings.NewReader(strings.Repeat("x", 1<<20))}
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+
+	record := logAttr(t, logger.GetSlogAttrFromRequest(req, logger.WithRequestBody(64)))
+	assert.Equal(t, strings.Repeat("x", 64), record["http.request.body.content"])
+	assert.LessOrEqual(t, body.read, 65)
+
+	rest, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Len(t, rest, 1<<20)
+
+	rw := logger.NewResponseWriter(httptest.NewRecorder())
+	rw.CaptureBody(64)
+	_, _ = rw.Write([]byte(strings.Repeat("x", 1<<20)))
+	assert.Len(t, rw.Body(), 64)
+}
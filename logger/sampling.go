@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cego/go-lib/v2/periodic"
+)
+
+// SamplingOptions configures NewSamplingHandler. Within each Interval the First records of a
+// level and message pass, after that every Thereafter-th record. A zero Thereafter drops the rest.
+type SamplingOptions struct {
+	First      int
+	Thereafter int
+	// Interval defaults to one second.
+	Interval time.Duration
+}
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+type sampleCount struct {
+	seen       int
+	suppressed int
+}
+
+type sampler struct {
+	opts   SamplingOptions
+	base   slog.Handler
+	mu     sync.Mutex
+	counts map[sampleKey]*sampleCount
+}
+
+type samplingHandler struct {
+	sampler *sampler
+	handler slog.Handler
+}
+
+// NewSamplingHandler wraps h, sampling records per level and message. At the end of every
+// interval a record with the number of suppressed records is written for each sampled message.
+// Sampling stops, and every record passes, once ctx is cancelled.
+func NewSamplingHandler(ctx context.Context, h slog.Handler, opts SamplingOptions) slog.Handler {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	s := &sampler{opts: opts, base: h, counts: map[sampleKey]*sampleCount{}}
+
+	// The jitter delays the first run, so summaries are written at the end of each interval.
+	periodic.Run(ctx, opts.Interval, opts.Interval, s.flush)
+	context.AfterFunc(ctx, s.stop)
+
+	return &samplingHandler{sampler: s, handler: h}
+}
+
+func (s *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.handler.Enabled(ctx, level)
+}
+
+func (s *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !s.sampler.sample(sampleKey{level: r.Level, message: r.Message}) {
+		return nil
+	}
+	return s.handler.Handle(ctx, r)
+}
+
+func (s *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{sampler: s.sampler, handler: s.handler.WithAttrs(attrs)}
+}
+
+func (s *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{sampler: s.sampler, handler: s.handler.WithGroup(name)}
+}
+
+func (s *sampler) sample(key sampleKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts == nil {
+		return true
+	}
+	count, ok := s.counts[key]
+	if !ok {
+		count = &sampleCount{}
+		s.counts[key] = count
+	}
+	count.seen++
+
+	if count.seen <= s.opts.First {
+		return true
+	}
+	if s.opts.Thereafter > 0 && (count.seen-s.opts.First)%s.opts.Thereafter == 0 {
+		return true
+	}
+	count.suppressed++
+	return false
+}
+
+func (s *sampler) flush() {
+	s.emit(s.swap(map[sampleKey]*sampleCount{}))
+}
+
+func (s *sampler) stop() {
+	s.emit(s.swap(nil))
+}
+
+// swap replaces the counts of the ended interval, a nil next disables sampling.
+func (s *sampler) swap(next map[sampleKey]*sampleCount) map[sampleKey]*sampleCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := s.counts
+	if counts != nil {
+		s.counts = next
+	}
+	return counts
+}
+
+func (s *sampler) emit(counts map[sampleKey]*sampleCount) {
+	for key, count := range counts {
+		if count.suppressed == 0 {
+			continue
+		}
+		r := slog.NewRecord(time.Now(), key.level, "log records suppressed by sampling", 0)
+		r.AddAttrs(
+			slog.Group("log.sampling",
+				slog.String("message", key.message),
+				slog.Int("suppressed", count.suppressed),
+				slog.Int("seen", count.seen),
+				slog.Duration("interval", s.opts.Interval),
+			),
+		)
+		_ = s.base.Handle(context.Background(), r)
+	}
+}
@@ -0,0 +1,66 @@
+package logger_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	t.Run("passes the first records then every nth per level and message", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rec := logtest.New()
+		l := slog.New(logger.NewSamplingHandler(ctx, rec, logger.SamplingOptions{First: 2, Thereafter: 3, Interval: time.Hour}))
+
+		for range 10 {
+			l.Error("database unavailable")
+		}
+		l.With("attempt", 1).Info("database unavailable")
+
+		assert.Len(t, rec.Find(slog.LevelError, "database unavailable"), 4) // 1, 2, 5, 8
+		assert.Len(t, rec.Find(slog.LevelInfo, "database unavailable", "attempt", 1), 1)
+	})
+
+	t.Run("writes a summary of suppressed records every interval", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rec := logtest.New()
+		l := slog.New(logger.NewSamplingHandler(ctx, rec, logger.SamplingOptions{First: 1, Interval: 50 * time.Millisecond}))
+
+		for range 5 {
+			l.Warn("upstream timeout")
+		}
+
+		assert.Eventually(t, func() bool {
+			return len(rec.Find(slog.LevelWarn, "suppressed by sampling",
+				"log.sampling.message", "upstream timeout",
+				"log.sampling.suppressed", 4,
+				"log.sampling.seen", 5,
+			)) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		l.Warn("upstream timeout")
+		assert.Len(t, rec.Find(slog.LevelWarn, "upstream timeout"), 2)
+	})
+
+	t.Run("stops sampling when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		rec := logtest.New()
+		l := slog.New(logger.NewSamplingHandler(ctx, rec, logger.SamplingOptions{Interval: time.Hour}))
+
+		l.Info("dropped")
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			return len(rec.Find(slog.LevelInfo, "suppressed by sampling", "log.sampling.suppressed", 1)) == 1
+		}, time.Second, 10*time.Millisecond)
+		l.Info("kept")
+		rec.AssertLogged(t, slog.LevelInfo, "kept")
+	})
+}
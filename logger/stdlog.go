@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// stdMessage parses a line of a standard library logger into a message and attributes.
+type stdMessage struct {
+	pattern *regexp.Regexp
+	message string
+	attrs   func(match []string) []any
+}
+
+var stdMessages = []stdMessage{
+	{
+		pattern: regexp.MustCompile(`^http: TLS handshake error from (\S+): (.*)$`),
+		message: "TLS handshake error",
+		attrs:   func(m []string) []any { return append(clientAddressAttrs(m[1]), "error.message", m[2]) },
+	},
+	{
+		pattern: regexp.MustCompile(`(?s)^http: panic serving (\S+): (.*)$`),
+		message: "panic serving request",
+		attrs:   func(m []string) []any { return append(clientAddressAttrs(m[1]), "error.message", m[2]) },
+	},
+	{
+		pattern: regexp.MustCompile(`^http: Accept error: (.*)$`),
+		message: "accept error",
+		attrs:   func(m []string) []any { return []any{"error.message", m[1]} },
+	},
+	{
+		pattern: regexp.MustCompile(`^http: superfluous response.WriteHeader call from (\S+)`),
+		message: "superfluous response.WriteHeader call",
+		attrs:   func(m []string) []any { return []any{"log.origin.function", m[1]} },
+	},
+}
+
+func clientAddressAttrs(address string) []any {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return []any{"client.address", address}
+	}
+	return []any{"client.address", address, "client.ip", host, "client.port", port}
+}
+
+type stdWriter struct {
+	logger Logger
+	level  slog.Level
+}
+
+// NewStdLogger returns a *log.Logger writing each line to l at level, e.g. for http.Server.ErrorLog
+// or libraries using the log package. Known net/http messages, like TLS handshake errors, are
+// parsed into ECS fields. A nil l writes to slog.Default() at the time of logging.
+func NewStdLogger(l Logger, level slog.Level) *log.Logger {
+	return log.New(&stdWriter{logger: l, level: level}, "", 0)
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	message, args := parseStdMessage(strings.TrimRight(string(p), "\r\n"))
+
+	l := w.logger
+	if l == nil {
+		l = slog.Default()
+	}
+
+	if sl, ok := l.(*slog.Logger); ok {
+		sl.Log(context.Background(), w.level, message, args...)
+		return len(p), nil
+	}
+
+	cl := AsContextLogger(l)
+	switch {
+	case w.level >= slog.LevelError:
+		cl.Error(message, args...)
+	case w.level >= slog.LevelWarn:
+		cl.Warn(message, args...)
+	case w.level >= slog.LevelInfo:
+		cl.Info(message, args...)
+	default:
+		cl.Debug(message, args...)
+	}
+	return len(p), nil
+}
+
+func parseStdMessage(line string) (string, []any) {
+	for _, m := range stdMessages {
+		if match := m.pattern.FindStringSubmatch(line); match != nil {
+			return m.message, m.attrs(match)
+		}
+	}
+	return line, nil
+}
@@ -0,0 +1,52 @@
+package logger_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStdLogger(t *testing.T) {
+	t.Run("parses net/http messages", func(t *testing.T) {
+		rec := logtest.New()
+		l := logger.NewStdLogger(rec.Logger(), slog.LevelWarn)
+
+		l.Printf("http: TLS handshake error from 192.0.2.10:41234: remote error: tls: bad certificate")
+		l.Printf("http: Accept error: accept tcp [::]:8080: too many open files; retrying in 5ms")
+		l.Println("plain message")
+
+		rec.AssertLogged(t, slog.LevelWarn, "TLS handshake error",
+			"client.address", "192.0.2.10:41234",
+			"client.ip", "192.0.2.10",
+			"client.port", "41234",
+			"error.message", "remote error: tls: bad certificate",
+		)
+		rec.AssertLogged(t, slog.LevelWarn, "accept error", "error.message", "accept tcp [::]:8080: too many open files; retrying in 5ms")
+		rec.AssertLogged(t, slog.LevelWarn, "plain message")
+	})
+
+	t.Run("maps levels onto a plain logger", func(t *testing.T) {
+		m := &logger.Mock{}
+		m.On("Error", "panic serving request", mock.Anything).Return()
+		m.On("Debug", "debug", mock.Anything).Return()
+
+		logger.NewStdLogger(m, slog.LevelError).Print("http: panic serving 10.0.0.1:1234: runtime error\ngoroutine 1 [running]:")
+		logger.NewStdLogger(m, slog.LevelDebug).Print("debug")
+
+		m.AssertExpectations(t)
+	})
+
+	t.Run("writes to the default logger when nil", func(t *testing.T) {
+		rec := logtest.New()
+		defer slog.SetDefault(slog.Default())
+		l := logger.NewStdLogger(nil, slog.LevelInfo)
+		slog.SetDefault(rec.Logger())
+
+		l.Print("late default")
+
+		rec.AssertLogged(t, slog.LevelInfo, "late default")
+	})
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is the facility part of the syslog priority.
+type SyslogFacility int
+
+const (
+	FacilityUser   SyslogFacility = 1
+	FacilityDaemon SyslogFacility = 3
+	FacilityLocal0 SyslogFacility = 16
+	FacilityLocal1 SyslogFacility = 17
+	FacilityLocal2 SyslogFacility = 18
+	FacilityLocal3 SyslogFacility = 19
+	FacilityLocal4 SyslogFacility = 20
+	FacilityLocal5 SyslogFacility = 21
+	FacilityLocal6 SyslogFacility = 22
+	FacilityLocal7 SyslogFacility = 23
+)
+
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// NewSyslogHandler returns a handler writing RFC 5424 messages to w, one per Write, e.g. to a
+// SyslogWriter. The message is the ECS JSON of New with dotted keys. The hostname and app name are
+// the host.name and service.name fields, os.Hostname() and the executable name without them.
+// The writer and format options are ignored.
+func NewSyslogHandler(w io.Writer, facility SyslogFacility, opts ...Option) slog.Handler {
+	o := newOptions(opts)
+	host := staticField(o, "host.name")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	app := staticField(o, "service.name")
+	if app == "" {
+		app = filepath.Base(os.Args[0])
+	}
+	header := " " + syslogHeaderField(host, 255) + " " + syslogHeaderField(app, 48) + " " + strconv.Itoa(os.Getpid()) + " - - "
+
+	var mu sync.Mutex
+	return newFieldsHandler(o, func(r slog.Record, attrs []slog.Attr) error {
+		// The stack trace, or the message without one, is cut to fit the limit of the writer.
+		longKey, long := "message", r.Message
+		for _, a := range attrs {
+			if a.Key == "error.stack_trace" {
+				longKey, long = a.Key, a.Value.String()
+			}
+		}
+
+		msg := fitMessage(maxMessageSize(w), long, func(long string) []byte {
+			body := &bytes.Buffer{}
+			body.WriteByte('{')
+			appendJSONField(body, "@timestamp", r.Time.UTC().Format(time.RFC3339Nano))
+			appendJSONField(body, "log.level", r.Level.String())
+			if longKey == "message" {
+				appendJSONField(body, "message", long)
+			} else {
+				appendJSONField(body, "message", r.Message)
+			}
+			for _, a := range attrs {
+				if a.Key == longKey {
+					appendJSONField(body, a.Key, long)
+				} else {
+					appendJSONField(body, a.Key, fieldValue(a.Value))
+				}
+			}
+			body.WriteByte('}')
+
+			buf := &bytes.Buffer{}
+			fmt.Fprintf(buf, "<%d>1 %s%s", int(facility)*8+severity(r.Level), r.Time.UTC().Format(syslogTimeFormat), header)
+			buf.Write(body.Bytes())
+			return buf.Bytes()
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := w.Write(msg)
+		return err
+	})
+}
+
+// syslogHeaderField makes s a valid header field, printable ASCII without spaces, at most max long.
+func syslogHeaderField(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	return s[:min(len(s), maxLen)]
+}
+
+// SyslogUDPMaxSize is the size limit of syslog messages over UDP, the default maximum message
+// size of rsyslog. Longer messages are truncated, as RFC 5424 suggests.
+const SyslogUDPMaxSize = 8192
+
+// SyslogWriter sends each Write as one syslog message, as a datagram over UDP and with octet
+// counting framing (RFC 6587) over TCP, where the connection is dialed again when a write fails.
+type SyslogWriter struct {
+	conn *netConn
+	mu   sync.Mutex
+}
+
+// Interface guard
+var _ io.WriteCloser = (*SyslogWriter)(nil)
+
+// NewSyslogWriter connects to a syslog server, network is "udp" or "tcp".
+func NewSyslogWriter(network string, address string) (*SyslogWriter, error) {
+	conn, err := dialNetConn("syslog", network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogWriter{conn: conn}, nil
+}
+
+// MaxMessageSize returns SyslogUDPMaxSize over UDP and 0 over TCP, which has no limit.
+func (s *SyslogWriter) MaxMessageSize() int {
+	if !s.conn.udp {
+		return 0
+	}
+	return SyslogUDPMaxSize
+}
+
+func (s *SyslogWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn.udp {
+		if err := s.conn.write(p[:min(len(p), SyslogUDPMaxSize)]); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	framed := append([]byte(strconv.Itoa(len(p))+" "), p...)
+	if err := s.conn.write(framed); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *SyslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.close()
+}
@@ -0,0 +1,112 @@
+package logger_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var syslogLine = regexp.MustCompile(`^<(\d+)>1 (\S+) (\S+) (\S+) (\d+) - - (\{.*\})$`)
+
+func TestSyslogHandler(t *testing.T) {
+	t.Run("writes rfc 5424 messages over udp", func(t *testing.T) {
+		server := listenUDP(t)
+		w, err := logger.NewSyslogWriter("udp", server.LocalAddr().String())
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+
+		l := slog.New(logger.NewSyslogHandler(w, logger.FacilityLocal0, logger.WithHostName("pod 1"), logger.WithServiceName("billing")))
+		ctx := logger.ContextWithTrace(context.Background(), logger.TraceContext{TraceID: "trace-1"})
+		l.ErrorContext(ctx, "payment failed", slog.Group("order", slog.Int("id", 42)))
+
+		match := syslogLine.FindStringSubmatch(string(readDatagram(t, server)))
+		require.NotNil(t, match)
+		assert.Equal(t, "131", match[1]) // local0 * 8 + error
+		assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}Z$`, match[2])
+		assert.Equal(t, "pod_1", match[3])
+		assert.Equal(t, "billing", match[4])
+		assert.Equal(t, strconv.Itoa(os.Getpid()), match[5])
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(match[6]), &record))
+		assert.Equal(t, "payment failed", record["message"])
+		assert.Equal(t, "ERROR", record["log.level"])
+		assert.Contains(t, record, "@timestamp")
+		assert.Equal(t, "billing", record["service.name"])
+		assert.Equal(t, "trace-1", record["trace.id"])
+		assert.InDelta(t, 42, record["order.id"], 0)
+	})
+
+	t.Run("frames messages with octet counting over tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+
+		w, err := logger.NewSyslogWriter("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		l := slog.New(logger.NewSyslogHandler(w, logger.FacilityUser))
+		l.Debug("first")
+		l.Info("second")
+
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		for _, expected := range []struct{ pri, message string }{{"<15>", "first"}, {"<14>", "second"}} {
+			length, err := reader.ReadString(' ')
+			require.NoError(t, err)
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			require.NoError(t, err)
+			frame := make([]byte, n)
+			_, err = io.ReadFull(reader, frame)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(frame), expected.pri+"1 "), string(frame))
+			assert.Contains(t, string(frame), `"message":"`+expected.message+`"`)
+		}
+	})
+
+	t.Run("limits udp messages", func(t *testing.T) {
+		server := listenUDP(t)
+		w, err := logger.NewSyslogWriter("udp", server.LocalAddr().String())
+		require.NoError(t, err)
+		defer func() { _ = w.Close() }()
+		assert.Equal(t, logger.SyslogUDPMaxSize, w.MaxMessageSize())
+
+		n, err := w.Write(make([]byte, 2*logger.SyslogUDPMaxSize))
+		require.NoError(t, err)
+		assert.Equal(t, 2*logger.SyslogUDPMaxSize, n)
+		assert.Len(t, readDatagram(t, server), logger.SyslogUDPMaxSize)
+
+		slog.New(logger.NewSyslogHandler(w, logger.FacilityUser)).Error("failed",
+			slog.Group("error", slog.String("stack_trace", strings.Repeat("main.go:1\n", 2000))))
+		datagram := readDatagram(t, server)
+		assert.LessOrEqual(t, len(datagram), logger.SyslogUDPMaxSize)
+		match := syslogLine.FindStringSubmatch(string(datagram))
+		require.NotNil(t, match)
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(match[6]), &record))
+		assert.Equal(t, "failed", record["message"])
+		assert.True(t, strings.HasSuffix(record["error.stack_trace"].(string), "...[truncated]"))
+	})
+
+	t.Run("reconnects over tcp", func(t *testing.T) {
+		assertReconnects(t, func(address string) (io.WriteCloser, error) {
+			return logger.NewSyslogWriter("tcp", address)
+		}, func(frame []byte, message string) bool {
+			return strings.Contains(string(frame), `"message":"`+message+`"`)
+		})
+	})
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+type traceContextKey struct{}
+
+type TraceContext struct {
+	TraceID string
+	// ParentID is the span id of the caller, from the traceparent header.
+	ParentID string
+	// SpanID is the id of a local span.
+	SpanID        string
+	TransactionID string
+}
+
+// ContextWithTrace returns a copy of ctx carrying tc, which the handler of New adds to every
+// record logged with that context as trace.id, parent.id, span.id and transaction.id.
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// ParseTraceparent parses a W3C traceparent header. The parent id becomes the ParentID,
+// SpanID and TransactionID are left for the caller to assign.
+func ParseTraceparent(value string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHexID(version, 2) || !isHexID(traceID, 32) || !isHexID(parentID, 16) || !isHexID(flags, 2) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	return TraceContext{TraceID: traceID, ParentID: parentID}, nil
+}
+
+func isHexID(s string, length int) bool {
+	if len(s) != length || strings.Trim(s, "0") == "" && length > 2 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func newSpanID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type traceHandler struct {
+	handler slog.Handler
+	// base is the handler before the first WithGroup, ops replays what followed on top of it,
+	// so trace fields stay top-level in grouped loggers.
+	base slog.Handler
+	ops  []func(slog.Handler) slog.Handler
+}
+
+// NewTraceHandler wraps h, adding the ECS trace.id, parent.id, span.id and transaction.id fields
+// of the TraceContext in the record's context.
+func NewTraceHandler(h slog.Handler) slog.Handler {
+	return &traceHandler{handler: h}
+}
+
+func (t *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return t.handler.Enabled(ctx, level)
+}
+
+func (t *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	tc, ok := TraceFromContext(ctx)
+	if !ok {
+		return t.handler.Handle(ctx, r)
+	}
+
+	var attrs []slog.Attr
+	if tc.TraceID != "" {
+		attrs = append(attrs, slog.String("trace.id", tc.TraceID))
+	}
+	if tc.ParentID != "" {
+		attrs = append(attrs, slog.String("parent.id", tc.ParentID))
+	}
+	if tc.SpanID != "" {
+		attrs = append(attrs, slog.String("span.id", tc.SpanID))
+	}
+	if tc.TransactionID != "" {
+		attrs = append(attrs, slog.String("transaction.id", tc.TransactionID))
+	}
+
+	if t.base == nil {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+		return t.handler.Handle(ctx, r)
+	}
+
+	h := t.base.WithAttrs(attrs)
+	for _, op := range t.ops {
+		h = op(h)
+	}
+	return h.Handle(ctx, r)
+}
+
+func (t *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return t.with(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) }, false)
+}
+
+func (t *traceHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return t
+	}
+	return t.with(func(h slog.Handler) slog.Handler { return h.WithGroup(name) }, true)
+}
+
+func (t *traceHandler) with(op func(slog.Handler) slog.Handler, group bool) *traceHandler {
+	next := &traceHandler{handler: op(t.handler), base: t.base, ops: t.ops}
+	if next.base == nil && group {
+		next.base = t.handler
+	}
+	if next.base != nil {
+		next.ops = append(t.ops[:len(t.ops):len(t.ops)], op)
+	}
+	return next
+}
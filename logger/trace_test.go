@@ -0,0 +1,92 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := logger.ParseTraceparent(traceparent)
+	require.NoError(t, err)
+	assert.Equal(t, logger.TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7"}, tc)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := logger.ParseTraceparent(invalid)
+		assert.ErrorIs(t, err, logger.ErrInvalidTraceparent, invalid)
+	}
+}
+
+func TestTraceHandler(t *testing.T) {
+	tc := logger.TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7", SpanID: "53995c3f42cd8ad8", TransactionID: "b7ad6b7169203331"}
+
+	t.Run("adds trace fields from context", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := slog.New(logger.NewTraceHandler(slog.NewJSONHandler(buf, nil)))
+
+		l.InfoContext(logger.ContextWithTrace(context.Background(), tc), "traced")
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, tc.TraceID, record["trace.id"])
+		assert.Equal(t, tc.ParentID, record["parent.id"])
+		assert.Equal(t, tc.SpanID, record["span.id"])
+		assert.Equal(t, tc.TransactionID, record["transaction.id"])
+	})
+
+	t.Run("keeps trace fields top-level in groups", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := slog.New(logger.NewTraceHandler(slog.NewJSONHandler(buf, nil))).With("a", 1).WithGroup("g").With("b", 2)
+
+		l.InfoContext(logger.ContextWithTrace(context.Background(), tc), "traced", "c", 3)
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, tc.TraceID, record["trace.id"])
+		assert.InDelta(t, 1, record["a"], 0)
+		assert.Equal(t, map[string]any{"b": float64(2), "c": float64(3)}, record["g"])
+	})
+
+	t.Run("leaves records without trace untouched", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := slog.New(logger.NewTraceHandler(slog.NewJSONHandler(buf, nil)))
+
+		l.Info("untraced")
+
+		assert.NotContains(t, decodeLine(t, buf), "trace.id")
+	})
+
+	t.Run("middleware parses incoming traceparent", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		l := slog.New(logger.NewTraceHandler(slog.NewJSONHandler(buf, nil)))
+		h := logger.ContextMiddleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.FromContext(r.Context()).InfoContext(r.Context(), "handled")
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(headers.Traceparent, traceparent)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		record := decodeLine(t, buf)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace.id"])
+		assert.Equal(t, "00f067aa0ba902b7", record["parent.id"])
+		assert.NotContains(t, record, "span.id")
+		assert.Len(t, record["transaction.id"], 16)
+	})
+}
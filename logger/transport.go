@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type TransportOption func(t *Transport)
+
+// WithTransportLevel sets the level for responses of a status class, 4 for 4xx and so on.
+// Failed round trips are logged at error.
+func WithTransportLevel(class int, level slog.Level) TransportOption {
+	return func(t *Transport) {
+		t.levels[class] = level
+	}
+}
+
+// WithTransportBody captures the first limit bytes of request and response bodies.
+// Bodies stay readable by the transport and the caller. Responses with a body are logged once the
+// caller has read the body to the end or closed it.
+func WithTransportBody(limit int) TransportOption {
+	return func(t *Transport) {
+		t.bodyLimit = limit
+	}
+}
+
+// WithTransportRedaction sets the policy used to mask headers, query and bodies, instead of the one
+// set with SetRedactionPolicy.
+func WithTransportRedaction(p *RedactionPolicy) TransportOption {
+	return func(t *Transport) {
+		t.policy = p
+	}
+}
+
+// Transport is an http.RoundTripper logging one ECS shaped record per outbound request.
+type Transport struct {
+	next      http.RoundTripper
+	logger    *slog.Logger
+	levels    map[int]slog.Level
+	bodyLimit int
+	policy    *RedactionPolicy
+}
+
+// Interface guard
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport wraps next, http.DefaultTransport when nil. If the request context carries a
+// request-scoped logger from ContextMiddleware, it is used instead of l. A nil l logs to
+// slog.Default().
+func NewTransport(l *slog.Logger, next http.RoundTripper, opts ...TransportOption) *Transport {
+	t := &Transport{
+		next:   next,
+		logger: l,
+		levels: map[int]slog.Level{1: slog.LevelDebug, 2: slog.LevelDebug, 3: slog.LevelDebug, 4: slog.LevelWarn, 5: slog.LevelError},
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := t.policy
+	if policy == nil {
+		policy = currentRedactionPolicy()
+	}
+
+	attrs := []slog.Attr{
+		slog.String("http.request.method", req.Method),
+		slog.String("url.full", redactedURL(req, policy)),
+		slog.String("server.address", req.URL.Hostname()),
+	}
+	attrs = appendHeadersAttr(attrs, "http.request.headers.raw", req.Header, policy)
+	if t.bodyLimit > 0 && req.Body != nil && req.Body != http.NoBody {
+		var body []byte
+		body, req = t.peekRequestBody(req)
+		attrs = append(attrs, slog.String("http.request.body.content", redactBody(body, req.Header, policy, t.bodyLimit)))
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	attrs = append(attrs, slog.Int64("event.duration", time.Since(start).Nanoseconds()))
+
+	l := t.logger
+	if _, ok := req.Context().Value(contextKey{}).(*scope); ok {
+		l = FromContext(req.Context())
+	} else if l == nil {
+		l = slog.Default()
+	}
+	target := req.URL.Host + req.URL.Path
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error.message", err.Error()), slog.String("error.type", fmt.Sprintf("%T", err)))
+		l.LogAttrs(req.Context(), slog.LevelError, fmt.Sprintf("%s %s failed", req.Method, target), attrs...)
+		return resp, err
+	}
+
+	level, ok := t.levels[resp.StatusCode/100]
+	if !ok {
+		level = slog.LevelDebug
+	}
+	if !l.Enabled(req.Context(), level) {
+		return resp, nil
+	}
+
+	message := fmt.Sprintf("%s %s %d", req.Method, target, resp.StatusCode)
+	attrs = appendResponseAttrs(attrs, resp, policy, 0)
+	if t.bodyLimit <= 0 || resp.Body == nil || resp.Body == http.NoBody || resp.StatusCode == http.StatusSwitchingProtocols {
+		l.LogAttrs(req.Context(), level, message, attrs...)
+		return resp, nil
+	}
+
+	// The body is captured while the caller reads it, so streaming responses are not held back
+	resp.Body = &loggedBody{ReadCloser: resp.Body, limit: t.bodyLimit, log: func(body []byte) {
+		attrs = append(attrs, slog.String("http.response.body.content", redactBody(body, resp.Header, policy, t.bodyLimit)))
+		l.LogAttrs(req.Context(), level, message, attrs...)
+	}}
+	return resp, nil
+}
+
+// loggedBody captures the start of a response body as it is read and logs it once the body is
+// read to the end or closed.
+type loggedBody struct {
+	io.ReadCloser
+	limit    int
+	log      func(body []byte)
+	mu       sync.Mutex
+	captured bytes.Buffer
+	logged   bool
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if remaining := captureSize(b.limit) - b.captured.Len(); remaining > 0 {
+		b.captured.Write(p[:min(n, remaining)])
+	}
+	b.mu.Unlock()
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+func (b *loggedBody) done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.logged {
+		b.logged = true
+		b.log(b.captured.Bytes())
+	}
+}
+
+// peekRequestBody returns the start of the request body and the request to send. RoundTrippers
+// must not modify the request, so the body is read from a copy made by GetBody, like net/http
+// does for retries. Without GetBody the body is peeked on a clone of the request, leaving the
+// caller's request as it was.
+func (t *Transport) peekRequestBody(req *http.Request) ([]byte, *http.Request) {
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			defer func() { _ = body.Close() }()
+			captured, _ := io.ReadAll(io.LimitReader(body, int64(captureSize(t.bodyLimit))))
+			return captured, req
+		}
+	}
+
+	req = req.Clone(req.Context())
+	return peekBody(&req.Body, t.bodyLimit), req
+}
+
+func redactedURL(req *http.Request, policy *RedactionPolicy) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = policy.RedactQuery(u.RawQuery)
+	return u.String()
+}
@@ -0,0 +1,176 @@
+package logger_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	t.Run("logs requests and responses with masking", func(t *testing.T) {
+		httpmock.Activate(t)
+		httpmock.RegisterResponder(http.MethodPost, "https://api.example.com/orders", func(r *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(r.Body)
+			resp := httpmock.NewStringResponse(http.StatusCreated, `{"id":1,"token":"t"}`)
+			resp.Header.Set(headers.ContentType, "application/json")
+			resp.Header.Set("X-Echo", string(body))
+			return resp, nil
+		})
+
+		rec := logtest.New()
+		client := &http.Client{Transport: logger.NewTransport(rec.Logger(), nil, logger.WithTransportBody(64))}
+		req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/orders?id=1&access_token=abc", strings.NewReader(`{"password":"p"}`))
+		req.Header.Set(headers.Authorization, "Bearer secret")
+		req.Header.Set(headers.ContentType, "application/json")
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.JSONEq(t, `{"id":1,"token":"t"}`, string(body))
+		assert.Equal(t, `{"password":"p"}`, resp.Header.Get("X-Echo"))
+
+		rec.AssertLogged(t, slog.LevelDebug, "POST api.example.com/orders 201",
+			"http.request.method", http.MethodPost,
+			"url.full", "https://api.example.com/orders?id=1&access_token=<masked>",
+			"server.address", "api.example.com",
+			"http.response.status_code", 201,
+		)
+		record := rec.Records()[0]
+		assert.JSONEq(t, `{"password":"<masked>"}`, record.Attrs["http.request.body.content"].(string))
+		assert.JSONEq(t, `{"id":1,"token":"<masked>"}`, record.Attrs["http.response.body.content"].(string))
+		assert.JSONEq(t, `{"Authorization":["<masked>"],"Content-Type":["application/json"]}`, record.Attrs["http.request.headers.raw"].(string))
+		assert.Contains(t, record.Attrs, "event.duration")
+	})
+
+	t.Run("logs streaming responses once the body is read", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(headers.ContentType, "text/event-stream")
+			_, _ = w.Write([]byte("data: first\n\n"))
+			w.(http.Flusher).Flush()
+			<-release
+			_, _ = w.Write([]byte("data: second\n\n"))
+		}))
+		defer server.Close()
+		defer func() {
+			select {
+			case <-release:
+			default:
+				close(release)
+			}
+		}()
+
+		rec := logtest.New()
+		client := &http.Client{Timeout: 5 * time.Second, Transport: logger.NewTransport(rec.Logger(), nil, logger.WithTransportBody(64))}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		first := make([]byte, len("data: first\n\n"))
+		_, err = io.ReadFull(resp.Body, first)
+		require.NoError(t, err)
+		assert.Equal(t, "data: first\n\n", string(first))
+		assert.Empty(t, rec.Records(), "the record is written once the body is read")
+
+		close(release)
+		_, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		rec.AssertLogged(t, slog.LevelDebug, "GET "+server.Listener.Addr().String()+" 200",
+			"http.response.body.content", "data: first\n\ndata: second\n\n")
+
+		require.NoError(t, resp.Body.Close())
+		assert.Len(t, rec.Records(), 1)
+	})
+
+	t.Run("logs responses closed before they are read", func(t *testing.T) {
+		httpmock.Activate(t)
+		httpmock.RegisterResponder(http.MethodGet, "https://api.example.com/orders", httpmock.NewStringResponder(http.StatusOK, "orders"))
+
+		rec := logtest.New()
+		client := &http.Client{Transport: logger.NewTransport(rec.Logger(), nil, logger.WithTransportBody(64))}
+		resp, err := client.Get("https://api.example.com/orders")
+		require.NoError(t, err)
+		assert.Empty(t, rec.Records())
+
+		require.NoError(t, resp.Body.Close())
+		rec.AssertLogged(t, slog.LevelDebug, "GET api.example.com/orders 200", "http.response.body.content", "")
+	})
+
+	t.Run("logs failures and status classes at their level", func(t *testing.T) {
+		httpmock.Activate(t)
+		httpmock.RegisterResponder(http.MethodGet, "https://api.example.com/missing", httpmock.NewStringResponder(http.StatusNotFound, ""))
+		httpmock.RegisterResponder(http.MethodGet, "https://api.example.com/down", httpmock.NewErrorResponder(errors.New("connection refused")))
+
+		rec := logtest.New()
+		client := &http.Client{Transport: logger.NewTransport(rec.Logger(), nil, logger.WithTransportLevel(4, slog.LevelInfo))}
+
+		resp, err := client.Get("https://api.example.com/missing")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		_, err = client.Get("https://api.example.com/down") //nolint:bodyclose // the request fails
+		require.Error(t, err)
+
+		rec.AssertLogged(t, slog.LevelInfo, "GET api.example.com/missing 404")
+		rec.AssertLogged(t, slog.LevelError, "GET api.example.com/down failed", "error.message", "connection refused")
+	})
+
+	t.Run("falls back to the default logger", func(t *testing.T) {
+		httpmock.Activate(t)
+		httpmock.RegisterResponder(http.MethodGet, "https://api.example.com/orders", httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+		rec := logtest.New()
+		defer slog.SetDefault(slog.Default())
+		slog.SetDefault(rec.Logger())
+
+		client := &http.Client{Transport: logger.NewTransport(nil, nil)}
+		require.NotPanics(t, func() {
+			resp, err := client.Get("https://api.example.com/orders")
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+		})
+		rec.AssertLogged(t, slog.LevelError, "GET api.example.com/orders 500")
+	})
+
+	t.Run("leaves the request of the caller untouched", func(t *testing.T) {
+		var sent *http.Request
+		var sentBody string
+		next := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			sent = r
+			body, _ := io.ReadAll(r.Body)
+			sentBody = string(body)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+		})
+		transport := logger.NewTransport(logtest.New().Logger(), next, logger.WithTransportBody(4))
+
+		req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/orders", strings.NewReader("hello world"))
+		_, err := transport.RoundTrip(req) //nolint:bodyclose // http.NoBody
+		require.NoError(t, err)
+		assert.Same(t, req, sent, "GetBody is used, the request is sent as is")
+		assert.Equal(t, "hello world", sentBody)
+
+		body := io.NopCloser(strings.NewReader("hello world"))
+		req, _ = http.NewRequest(http.MethodPost, "https://api.example.com/orders", body)
+		_, err = transport.RoundTrip(req) //nolint:bodyclose // http.NoBody
+		require.NoError(t, err)
+		assert.NotSame(t, req, sent)
+		assert.Equal(t, body, req.Body)
+		assert.Equal(t, "hello world", sentBody)
+	})
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("renders counters", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		c := reg.Counter("requests_total", "Requests by code.", "code")
+		c.Inc("200")
+		c.Inc("200")
+		c.Add(0.5, `we"ird`)
+
+		var sb strings.Builder
+		_, err := reg.WriteTo(&sb)
+		require.NoError(t, err)
+		assert.Equal(t, `# HELP requests_total Requests by code.
+# TYPE requests_total counter
+requests_total{code="200"} 2
+requests_total{code="we\"ird"} 0.5
+`, sb.String())
+	})
+
+	t.Run("renders histograms", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		h := reg.Histogram("latency_seconds", "Latency.", []float64{1, 0.1})
+		h.Observe(0.05)
+		h.Observe(0.5)
+		h.Observe(3)
+
+		var sb strings.Builder
+		_, err := reg.WriteTo(&sb)
+		require.NoError(t, err)
+		assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.55
+latency_seconds_count 3
+`, sb.String())
+	})
+
+	t.Run("serves exposition over http", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		reg.Counter("up", "Up.").Inc()
+
+		rec := httptest.NewRecorder()
+		reg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get(headers.ContentType))
+		assert.Contains(t, rec.Body.String(), "up 1\n")
+	})
+
+	t.Run("panics on label mismatch and duplicate names", func(t *testing.T) {
+		reg := metrics.NewRegistry()
+		c := reg.Counter("requests_total", "Requests.", "code")
+		assert.Panics(t, func() { c.Inc() })
+		assert.Panics(t, func() { reg.Counter("requests_total", "Requests.") })
+	})
+}
@@ -0,0 +1,150 @@
+package recovery
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/renderer"
+)
+
+type Option func(r *Recovery)
+
+// WithRepanicAbort re-panics http.ErrAbortHandler, so net/http aborts the response without a log
+// record, instead of logging it and responding with 500 like any other panic.
+func WithRepanicAbort() Option {
+	return func(r *Recovery) {
+		r.repanicAbort = true
+	}
+}
+
+// WithRequestAttrOptions sets options of the request attributes, which are the ECS fields of
+// logger.GetSlogAttrFromRequest by default.
+func WithRequestAttrOptions(opts ...logger.RequestAttrOption) Option {
+	return func(r *Recovery) {
+		r.requestOpts = append(r.requestOpts, opts...)
+	}
+}
+
+// PanicError is the error logged for a panic with a value that is not an error.
+type PanicError struct {
+	Value any
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprint(p.Value)
+}
+
+type Recovery struct {
+	logger       *slog.Logger
+	renderer     *renderer.Renderer
+	repanicAbort bool
+	requestOpts  []logger.RequestAttrOption
+}
+
+// New returns recovery middleware logging to l. Without l the request-scoped logger of
+// logger.ContextMiddleware is used, or slog.Default() if there is none.
+func New(l *slog.Logger, opts ...Option) *Recovery {
+	rl := l
+	if rl == nil {
+		rl = slog.Default()
+	}
+	r := &Recovery{
+		logger:      l,
+		renderer:    renderer.New(rl),
+		requestOpts: []logger.RequestAttrOption{logger.WithECSFields()},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (rc *Recovery) HandlerFunc(handlerFunc http.HandlerFunc) http.Handler {
+	return rc.Handler(handlerFunc)
+}
+
+// Handler recovers panics of handler, logs them with the stack of the panic and the request and
+// responds with 500, unless the response has already been started.
+func (rc *Recovery) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if rc.repanicAbort && value == http.ErrAbortHandler {
+				panic(value)
+			}
+
+			err, ok := value.(error)
+			if !ok {
+				err = &PanicError{Value: value}
+			}
+			l := rc.logger
+			if l == nil {
+				l = logger.FromContext(r.Context())
+			}
+			l.LogAttrs(r.Context(), slog.LevelError, fmt.Sprintf("panic serving %s %s: %v", r.Method, r.URL.Path, err),
+				logger.GetSlogAttrFromError(err),
+				logger.GetSlogAttrFromRequest(r, rc.requestOpts...),
+			)
+
+			if !rw.wroteHeader {
+				rc.renderer.WithContext(r.Context()).Text(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}
+		}()
+
+		handler.ServeHTTP(rw, r)
+	})
+}
+
+// responseWriter tracks whether the response has been started, after which the status can no
+// longer be changed.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// Interface guard
+var (
+	_ http.Flusher  = (*responseWriter)(nil)
+	_ http.Hijacker = (*responseWriter)(nil)
+)
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if status >= http.StatusOK || status == http.StatusSwitchingProtocols {
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *responseWriter) Flush() {
+	rw.wroteHeader = true
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		// The connection is no longer a response that can be written to.
+		rw.wroteHeader = true
+	}
+	return conn, brw, err
+}
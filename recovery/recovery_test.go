@@ -0,0 +1,147 @@
+package recovery_test
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cego/go-lib/v2/headers"
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
+	"github.com/cego/go-lib/v2/recovery"
+	"github.com/cego/go-lib/v2/stackerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecovery(t *testing.T) {
+	t.Run("logs the panic and responds with 500", func(t *testing.T) {
+		rec := logtest.New()
+		handler := recovery.New(rec.Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var m map[string]int
+			m["boom"]++
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders?id=1", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "Internal Server Error", w.Body.String())
+		rec.AssertLogged(t, slog.LevelError, "panic serving POST /orders: assignment to entry in nil map",
+			"error.message", "assignment to entry in nil map",
+			"error.type", "runtime.plainError",
+			"http.request.method", "POST",
+			"url.path", "/orders",
+		)
+		records := rec.Find(slog.LevelError, "panic serving")
+		require.Len(t, records, 1)
+		assert.Contains(t, records[0].Attrs["error.stack_trace"], "recovery_test.TestRecovery")
+	})
+
+	t.Run("wraps values that are not errors", func(t *testing.T) {
+		rec := logtest.New()
+		handler := recovery.New(rec.Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(42)
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		rec.AssertLogged(t, slog.LevelError, "panic serving GET /", "error.message", "42", "error.type", "*recovery.PanicError")
+	})
+
+	t.Run("uses the stack captured by stackerr", func(t *testing.T) {
+		rec := logtest.New()
+		err := stackerr.New("invariant violated")
+		handler := recovery.New(rec.Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(err)
+		})
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		rec.AssertLogged(t, slog.LevelError, "invariant violated", "error.stack_trace", stackerr.Format(stackerr.StackTrace(err)))
+	})
+
+	t.Run("keeps a started response", func(t *testing.T) {
+		rec := logtest.New()
+		handler := recovery.New(rec.Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte("partial"))
+			panic(errors.New("stream failed"))
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "partial", w.Body.String())
+		rec.AssertLogged(t, slog.LevelError, "stream failed")
+	})
+
+	t.Run("uses the request scoped logger without a logger", func(t *testing.T) {
+		rec := logtest.New()
+		handler := logger.ContextMiddleware(rec.Logger(), recovery.New(nil).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(headers.XRequestId, "req-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		rec.AssertLogged(t, slog.LevelError, "boom", "http.request.id", "req-1")
+	})
+
+	t.Run("keeps a flushed response", func(t *testing.T) {
+		rec := logtest.New()
+		handler := recovery.New(rec.Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.(http.Flusher).Flush()
+			panic("after flush")
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.True(t, w.Flushed)
+		assert.Empty(t, w.Body.String())
+		rec.AssertLogged(t, slog.LevelError, "after flush")
+	})
+
+	t.Run("passes hijacking through", func(t *testing.T) {
+		handler := recovery.New(logtest.New().Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _, err := w.(http.Hijacker).Hijack()
+			assert.ErrorIs(t, err, http.ErrNotSupported)
+		})
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("re-panics http.ErrAbortHandler", func(t *testing.T) {
+		rec := logtest.New()
+		handler := recovery.New(rec.Logger(), recovery.WithRepanicAbort()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		assert.Empty(t, rec.Records())
+	})
+
+	t.Run("logs http.ErrAbortHandler without re-panicking", func(t *testing.T) {
+		rec := logtest.New()
+		handler := recovery.New(rec.Logger()).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		rec.AssertLogged(t, slog.LevelError, "net/http: abort Handler")
+	})
+}
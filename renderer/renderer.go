@@ -1,6 +1,7 @@
 package renderer
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -9,11 +10,25 @@ import (
 )
 
 type Renderer struct {
-	logger logger.Logger
+	logger logger.ContextLogger
+	ctx    context.Context
 }
 
 func New(l logger.Logger) *Renderer {
-	return &Renderer{logger: l}
+	return &Renderer{logger: logger.AsContextLogger(l)}
+}
+
+// WithContext returns a renderer logging write errors with ctx, typically the request context.
+func (r *Renderer) WithContext(ctx context.Context) *Renderer {
+	return &Renderer{logger: r.logger, ctx: ctx}
+}
+
+func (r *Renderer) logError(err error) {
+	if r.ctx == nil {
+		r.logger.Error(err.Error())
+		return
+	}
+	r.logger.ErrorContext(r.ctx, err.Error())
 }
 
 func (r *Renderer) JSON(writer http.ResponseWriter, status int, data interface{}) {
@@ -22,7 +37,7 @@ func (r *Renderer) JSON(writer http.ResponseWriter, status int, data interface{}
 
 	err := json.NewEncoder(writer).Encode(data)
 	if err != nil {
-		r.logger.Error(err.Error())
+		r.logError(err)
 		return
 	}
 }
@@ -33,7 +48,7 @@ func (r *Renderer) Text(writer http.ResponseWriter, status int, text string) {
 
 	_, err := writer.Write([]byte(text))
 	if err != nil {
-		r.logger.Error(err.Error())
+		r.logError(err)
 		return
 	}
 }
@@ -44,7 +59,7 @@ func (r *Renderer) Data(writer http.ResponseWriter, status int, bytes []byte, co
 
 	_, err := writer.Write(bytes)
 	if err != nil {
-		r.logger.Error(err.Error())
+		r.logError(err)
 		return
 	}
 }
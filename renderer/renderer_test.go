@@ -1,6 +1,7 @@
 package renderer_test
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -119,4 +120,19 @@ func TestRenderer(t *testing.T) {
 
 		l.AssertExpectations(t)
 	})
+
+	t.Run("logs error with the given context", func(t *testing.T) {
+		l := &logger.Mock{}
+		r := renderer.New(l)
+		ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+		l.On("ErrorContext", ctx, "forced write error", mock.Anything).Return()
+
+		r.WithContext(ctx).Text(&FaultyResponseWriter{}, http.StatusOK, "hello")
+
+		l.AssertExpectations(t)
+		l.AssertNotCalled(t, "Error", mock.Anything, mock.Anything)
+	})
 }
+
+type ctxKey struct{}
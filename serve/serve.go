@@ -2,10 +2,14 @@ package serve
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/cego/go-lib/v2/logger"
 )
 
 const (
@@ -17,10 +21,21 @@ const (
 	DefaultDrainTimeout      = 10 * time.Second
 )
 
+// Flusher is implemented by buffering writers such as logger.AsyncHandler.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
 type Server struct {
 	*http.Server
 	ShutdownDelay time.Duration
 	DrainTimeout  time.Duration
+	// Flushers are flushed when ListenAndServe returns, bounded by DrainTimeout. Their errors are
+	// returned with the one of the server.
+	Flushers []Flusher
+
+	// defaultErrorLog is the ErrorLog set by WithDefaults, which ListenAndServe replaces.
+	defaultErrorLog *log.Logger
 }
 
 func WithDefaults(srv *http.Server) *Server {
@@ -36,25 +51,43 @@ func WithDefaults(srv *http.Server) *Server {
 	if srv.IdleTimeout == 0 {
 		srv.IdleTimeout = DefaultIdleTimeout
 	}
+	var defaultErrorLog *log.Logger
+	if srv.ErrorLog == nil {
+		// TLS handshake errors are constant on public listeners, so they do not warrant error.
+		defaultErrorLog = logger.NewStdLogger(nil, slog.LevelWarn)
+		srv.ErrorLog = defaultErrorLog
+	}
 
 	return &Server{
-		Server:        srv,
-		ShutdownDelay: DefaultShutdownDelay,
-		DrainTimeout:  DefaultDrainTimeout,
+		Server:          srv,
+		ShutdownDelay:   DefaultShutdownDelay,
+		DrainTimeout:    DefaultDrainTimeout,
+		defaultErrorLog: defaultErrorLog,
 	}
 }
 
-func ListenAndServe(ctx context.Context, srv *Server, logger *slog.Logger) error {
-	return listenAndShutdown(ctx, srv, logger, srv.ListenAndServe)
+// ListenAndServe serves until ctx is done and then shuts down gracefully. A nil l logs to slog.Default().
+// A non-nil l also replaces the ErrorLog set by WithDefaults, or a nil one.
+func ListenAndServe(ctx context.Context, srv *Server, l *slog.Logger) error {
+	return listenAndShutdown(ctx, srv, l, srv.ListenAndServe)
 }
 
-func ListenAndServeTLS(ctx context.Context, srv *Server, logger *slog.Logger, certFile, keyFile string) error {
-	return listenAndShutdown(ctx, srv, logger, func() error {
+func ListenAndServeTLS(ctx context.Context, srv *Server, l *slog.Logger, certFile, keyFile string) error {
+	return listenAndShutdown(ctx, srv, l, func() error {
 		return srv.ListenAndServeTLS(certFile, keyFile)
 	})
 }
 
-func listenAndShutdown(ctx context.Context, srv *Server, logger *slog.Logger, startFn func() error) error {
+func listenAndShutdown(ctx context.Context, srv *Server, l *slog.Logger, startFn func() error) error {
+	given := l != nil
+	if !given {
+		l = slog.Default()
+	}
+	l = logger.WithName(l, "serve")
+	if given && (srv.ErrorLog == nil || srv.ErrorLog == srv.defaultErrorLog) {
+		srv.ErrorLog = logger.NewStdLogger(l, slog.LevelWarn)
+	}
+
 	serverErrors := make(chan error, 1)
 	go func() {
 		serverErrors <- startFn()
@@ -62,20 +95,36 @@ func listenAndShutdown(ctx context.Context, srv *Server, logger *slog.Logger, st
 
 	select {
 	case err := <-serverErrors:
-		return err
+		return errors.Join(err, flush(srv))
 	case <-ctx.Done():
-		logger.Debug(fmt.Sprintf("shutdown signal received, waiting %s for load balancer to deregister", srv.ShutdownDelay))
+		l.Debug(fmt.Sprintf("shutdown signal received, waiting %s for load balancer to deregister", srv.ShutdownDelay))
 		time.Sleep(srv.ShutdownDelay)
 
-		logger.Debug("draining existing connections")
+		l.Debug("draining existing connections")
 		drainCtx, cancel := context.WithTimeout(context.Background(), srv.DrainTimeout)
 		defer cancel()
 
 		if err := srv.Shutdown(drainCtx); err != nil {
-			return fmt.Errorf("shutdown failed: %w", err)
+			return errors.Join(fmt.Errorf("shutdown failed: %w", err), flush(srv))
 		}
 
-		logger.Debug("server shutdown complete")
+		l.Debug("server shutdown complete")
+		return flush(srv)
+	}
+}
+
+func flush(srv *Server) error {
+	if len(srv.Flushers) == 0 {
+		return nil
+	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), srv.DrainTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, f := range srv.Flushers {
+		if err := f.Flush(flushCtx); err != nil {
+			errs = append(errs, fmt.Errorf("flush failed: %w", err))
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
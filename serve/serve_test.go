@@ -2,6 +2,9 @@ package serve_test
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log"
 	"log/slog"
 	"net"
 	"net/http"
@@ -10,6 +13,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cego/go-lib/v2/logger"
+	"github.com/cego/go-lib/v2/logger/logtest"
 	"github.com/cego/go-lib/v2/serve"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +42,56 @@ func TestWithDefaults(t *testing.T) {
 		assert.Equal(t, 60*time.Second, result.WriteTimeout)
 		assert.Equal(t, serve.DefaultIdleTimeout, result.IdleTimeout)
 	})
+
+	t.Run("logs server errors to the default logger", func(t *testing.T) {
+		rec := logtest.New()
+		defer slog.SetDefault(slog.Default())
+		result := serve.WithDefaults(&http.Server{})
+		slog.SetDefault(rec.Logger())
+
+		result.ErrorLog.Printf("http: TLS handshake error from 10.0.0.1:53211: EOF")
+
+		rec.AssertLogged(t, slog.LevelWarn, "TLS handshake error", "client.ip", "10.0.0.1", "error.message", "EOF")
+	})
+
+	t.Run("preserves existing error log", func(t *testing.T) {
+		errorLog := log.New(io.Discard, "", 0)
+		result := serve.WithDefaults(&http.Server{ErrorLog: errorLog})
+
+		assert.Same(t, errorLog, result.ErrorLog)
+	})
+}
+
+func TestListenAndServe_ErrorLog(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	t.Run("logs server errors to the given logger", func(t *testing.T) {
+		rec := logtest.New()
+		srv := serve.WithDefaults(&http.Server{Addr: listener.Addr().String()})
+
+		require.Error(t, serve.ListenAndServe(context.Background(), srv, rec.Logger()))
+		srv.ErrorLog.Printf("http: TLS handshake error from 10.0.0.1:53211: EOF")
+
+		rec.AssertLogged(t, slog.LevelWarn, "TLS handshake error", "log.logger", "serve", "client.ip", "10.0.0.1", "error.message", "EOF")
+	})
+
+	t.Run("preserves existing error log", func(t *testing.T) {
+		errorLog := log.New(io.Discard, "", 0)
+		srv := serve.WithDefaults(&http.Server{Addr: listener.Addr().String(), ErrorLog: errorLog})
+
+		require.Error(t, serve.ListenAndServe(context.Background(), srv, slog.Default()))
+		assert.Same(t, errorLog, srv.ErrorLog)
+	})
+
+	t.Run("keeps the default error log without a logger", func(t *testing.T) {
+		srv := serve.WithDefaults(&http.Server{Addr: listener.Addr().String()})
+		errorLog := srv.ErrorLog
+
+		require.Error(t, serve.ListenAndServe(context.Background(), srv, nil))
+		assert.Same(t, errorLog, srv.ErrorLog)
+	})
 }
 
 func TestListenAndServe_ServerError(t *testing.T) {
@@ -50,6 +105,10 @@ func TestListenAndServe_ServerError(t *testing.T) {
 
 	err = serve.ListenAndServe(context.Background(), srv, slog.Default())
 	assert.Error(t, err)
+
+	assert.NotPanics(t, func() {
+		assert.Error(t, serve.ListenAndServe(context.Background(), srv, nil))
+	})
 }
 
 func TestListenAndServe_GracefulShutdown(t *testing.T) {
@@ -75,6 +134,79 @@ func TestListenAndServe_GracefulShutdown(t *testing.T) {
 	}
 }
 
+func TestListenAndServe_FlushesOnShutdown(t *testing.T) {
+	rec := logtest.New()
+	async := logger.NewAsyncHandler(slowHandler{Handler: rec}, logger.AsyncOptions{})
+	srv := serve.WithDefaults(&http.Server{Addr: ":0", Handler: http.NewServeMux()})
+	srv.ShutdownDelay = 10 * time.Millisecond
+	srv.DrainTimeout = time.Second
+	srv.Flushers = append(srv.Flushers, async)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, serve.ListenAndServe(ctx, srv, slog.New(async)))
+	rec.AssertLogged(t, slog.LevelDebug, "server shutdown complete")
+}
+
+type flusherFunc func(ctx context.Context) error
+
+func (f flusherFunc) Flush(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestListenAndServe_FlushErrors(t *testing.T) {
+	flushErr := errors.New("buffer stuck")
+	flushed := 0
+	newServer := func(addr string) *serve.Server {
+		srv := serve.WithDefaults(&http.Server{Addr: addr, Handler: http.NewServeMux()})
+		srv.ShutdownDelay = 10 * time.Millisecond
+		srv.DrainTimeout = 100 * time.Millisecond
+		srv.Flushers = append(srv.Flushers, flusherFunc(func(ctx context.Context) error {
+			flushed++
+			return flushErr
+		}))
+		return srv
+	}
+
+	t.Run("reports flush errors after shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := serve.ListenAndServe(ctx, newServer(":0"), logtest.New().Logger())
+		require.ErrorIs(t, err, flushErr)
+		assert.Equal(t, 1, flushed)
+	})
+
+	t.Run("flushes when the server fails to start", func(t *testing.T) {
+		listener, err := net.Listen("tcp", ":0")
+		require.NoError(t, err)
+		defer func() { _ = listener.Close() }()
+
+		err = serve.ListenAndServe(context.Background(), newServer(listener.Addr().String()), logtest.New().Logger())
+		require.ErrorIs(t, err, flushErr)
+		assert.ErrorContains(t, err, "address already in use")
+		assert.Equal(t, 2, flushed)
+	})
+}
+
+type slowHandler struct {
+	slog.Handler
+}
+
+func (s slowHandler) Handle(ctx context.Context, r slog.Record) error {
+	time.Sleep(20 * time.Millisecond)
+	return s.Handler.Handle(ctx, r)
+}
+
+func (s slowHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return slowHandler{Handler: s.Handler.WithAttrs(attrs)}
+}
+
+func (s slowHandler) WithGroup(name string) slog.Handler {
+	return slowHandler{Handler: s.Handler.WithGroup(name)}
+}
+
 func TestListenAndServe_SignalShutdown(t *testing.T) {
 	srv := serve.WithDefaults(&http.Server{Addr: ":0", Handler: http.NewServeMux()})
 	srv.ShutdownDelay = 50 * time.Millisecond
@@ -0,0 +1,160 @@
+package stackerr
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const maxDepth = 64
+
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+func (f Frame) String() string {
+	return f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line)
+}
+
+type stackError struct {
+	err   error
+	stack []uintptr
+}
+
+func (s *stackError) Error() string {
+	return s.err.Error()
+}
+
+func (s *stackError) Unwrap() error {
+	return s.err
+}
+
+// New returns an error with the given message and the stack of the caller.
+func New(message string) error {
+	return &stackError{err: errors.New(message), stack: callers()}
+}
+
+// Errorf formats like fmt.Errorf and captures the stack of the caller, unless a wrapped error
+// already carries one.
+func Errorf(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if HasStack(err) {
+		return err
+	}
+	return &stackError{err: err, stack: callers()}
+}
+
+// Wrap captures the stack of the caller for err, unless err already carries one. Wrap(nil) is nil.
+func Wrap(err error) error {
+	if err == nil || HasStack(err) {
+		return err
+	}
+	return &stackError{err: err, stack: callers()}
+}
+
+func HasStack(err error) bool {
+	var s *stackError
+	return errors.As(err, &s)
+}
+
+// StackTrace returns the frames captured closest to the origin of err, or nil if there are none.
+func StackTrace(err error) []Frame {
+	var found *stackError
+	walk(err, func(e error) {
+		if s, ok := e.(*stackError); ok {
+			found = s
+		}
+	})
+	if found == nil {
+		return nil
+	}
+	return frames(found.stack)
+}
+
+// Callers returns the stack of the caller, skipping skip additional frames.
+func Callers(skip int) []Frame {
+	pcs := make([]uintptr, maxDepth)
+	n := runtime.Callers(skip+2, pcs)
+	return frames(pcs[:n])
+}
+
+// Origin returns the first error in the chain that is not a stack capturing wrapper.
+func Origin(err error) error {
+	for {
+		s, ok := err.(*stackError)
+		if !ok {
+			return err
+		}
+		err = s.err
+	}
+}
+
+// Causes returns the wrapped errors of err depth first, including errors.Join members and
+// excluding err itself and stack capturing wrappers.
+func Causes(err error) []error {
+	var causes []error
+	for _, child := range unwrap(Origin(err)) {
+		walk(child, func(e error) {
+			if _, ok := e.(*stackError); !ok {
+				causes = append(causes, e)
+			}
+		})
+	}
+	return causes
+}
+
+// Format renders frames like runtime/debug.Stack, one function and file:line per frame.
+func Format(frames []Frame) string {
+	var sb strings.Builder
+	for _, f := range frames {
+		sb.WriteString(f.String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func walk(err error, visit func(error)) {
+	if err == nil {
+		return
+	}
+	visit(err)
+	for _, child := range unwrap(err) {
+		walk(child, visit)
+	}
+}
+
+func unwrap(err error) []error {
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if child := u.Unwrap(); child != nil {
+			return []error{child}
+		}
+	case interface{ Unwrap() []error }:
+		return u.Unwrap()
+	}
+	return nil
+}
+
+func callers() []uintptr {
+	pcs := make([]uintptr, maxDepth)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+func frames(pcs []uintptr) []Frame {
+	var result []Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		frame, more := it.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && frame.Function != "" {
+			result = append(result, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			return result
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/cego/go-lib/v2/stackerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func origin() error {
+	return stackerr.New("origin failed")
+}
+
+func TestStackTrace(t *testing.T) {
+	t.Run("captures the stack at creation", func(t *testing.T) {
+		err := fmt.Errorf("handler: %w", origin())
+
+		frames := stackerr.StackTrace(err)
+		require.NotEmpty(t, frames)
+		assert.Equal(t, "github.com/cego/go-lib/v2/stackerr_test.origin", frames[0].Function)
+		assert.True(t, strings.HasSuffix(frames[0].File, "stackerr_test.go"))
+		assert.Equal(t, "handler: origin failed", err.Error())
+	})
+
+	t.Run("keeps the innermost stack when wrapping", func(t *testing.T) {
+		err := stackerr.Wrap(stackerr.Errorf("outer: %w", origin()))
+
+		assert.Equal(t, "github.com/cego/go-lib/v2/stackerr_test.origin", stackerr.StackTrace(err)[0].Function)
+	})
+
+	t.Run("wraps plain errors", func(t *testing.T) {
+		assert.NoError(t, stackerr.Wrap(nil))
+		assert.Nil(t, stackerr.StackTrace(errors.New("plain")))
+
+		err := stackerr.Wrap(fs.ErrNotExist)
+		assert.True(t, stackerr.HasStack(err))
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		assert.Contains(t, stackerr.StackTrace(err)[0].Function, "TestStackTrace")
+	})
+
+	t.Run("formats frames", func(t *testing.T) {
+		out := stackerr.Format([]stackerr.Frame{{Function: "main.main", File: "/app/main.go", Line: 12}})
+		assert.Equal(t, "main.main\n\t/app/main.go:12\n", out)
+	})
+}
+
+func TestCauses(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/etc/app.yml", Err: fs.ErrNotExist}
+	err := stackerr.Wrap(fmt.Errorf("load config: %w", errors.Join(pathErr, errors.New("fallback missing"))))
+
+	causes := stackerr.Causes(err)
+
+	require.Len(t, causes, 4)
+	assert.Equal(t, "open /etc/app.yml: file does not exist\nfallback missing", causes[0].Error())
+	assert.Same(t, pathErr, causes[1])
+	assert.Equal(t, fs.ErrNotExist, causes[2])
+	assert.Equal(t, "fallback missing", causes[3].Error())
+	assert.IsType(t, &fs.PathError{}, stackerr.Origin(stackerr.Wrap(pathErr)))
+}